@@ -0,0 +1,193 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLocalsKey is the locals key under which ValidateBody stores the bound DTO
+const BodyLocalsKey = "body"
+
+// Bind builds a T from the request: `default:"…"` values first, then the body,
+// `query:"…"` parameters, `params:"…"` path parameters and `header:"…"` headers,
+// each source overriding the previous one. The result is validated with messages in
+// the request's Accept-Language, and invalid input is reported as a 422 HttpException
+// carrying the []*ValidationError list (grouped by field when the App has GroupValidationErrors enabled).
+func Bind[T any](c *fiber.Ctx) (T, error) {
+	var dto T
+
+	target := reflect.ValueOf(&dto).Elem()
+	if target.Kind() != reflect.Struct {
+		return dto, fmt.Errorf("bind target must be a struct, got %s", target.Type())
+	}
+
+	if err := applyDefaults(target); err != nil {
+		return dto, err
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&dto); err != nil {
+			return dto, BadRequest("Invalid request body").WithCause(err)
+		}
+	}
+
+	if len(c.Request().URI().QueryString()) > 0 {
+		if err := c.QueryParser(&dto); err != nil {
+			return dto, BadRequest("Invalid query parameters").WithCause(err)
+		}
+	}
+
+	if len(c.Route().Params) > 0 {
+		if err := c.ParamsParser(&dto); err != nil {
+			return dto, BadRequest("Invalid path parameters").WithCause(err)
+		}
+	}
+
+	if err := bindHeaders(c, target); err != nil {
+		return dto, BadRequest("Invalid request headers").WithCause(err)
+	}
+
+	if errs := ValidateRequest(c, dto); len(errs) > 0 {
+		if appOf(c).groupValidationErrors() {
+			return dto, UnprocessableEntity("Validation failed").WithData(GroupByField(errs))
+		}
+		return dto, UnprocessableEntity("Validation failed").WithData(errs)
+	}
+
+	return dto, nil
+}
+
+// ValidateBody binds and validates a T before the handler runs and stores it in
+// locals; handlers read it back with Body[T](c)
+func ValidateBody[T any]() Middleware {
+	return HandlerMiddleware{
+		Handler: func(c *fiber.Ctx) error {
+			dto, err := Bind[T](c)
+			if err != nil {
+				return err
+			}
+			c.Locals(BodyLocalsKey, dto)
+			return c.Next()
+		},
+	}
+}
+
+// Body returns the DTO stored by ValidateBody
+func Body[T any](c *fiber.Ctx) T {
+	dto, _ := c.Locals(BodyLocalsKey).(T)
+	return dto
+}
+
+// applyDefaults sets `default:"…"` values on zero-valued fields, recursing into nested structs
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldVal := v.Field(i)
+		if !fieldVal.CanSet() {
+			continue
+		}
+
+		if fieldVal.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			if err := applyDefaults(fieldVal); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := field.Tag.Lookup("default")
+		if !ok || !fieldVal.IsZero() {
+			continue
+		}
+		if err := setFromString(fieldVal, value); err != nil {
+			return fmt.Errorf("invalid default for %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// bindHeaders copies `header:"…"` request headers into the matching fields
+func bindHeaders(c *fiber.Ctx, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("header")
+		if name == "" || name == "-" || !v.Field(i).CanSet() {
+			continue
+		}
+
+		value := c.Get(name)
+		if value == "" {
+			continue
+		}
+		if err := setFromString(v.Field(i), value); err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromString assigns a textual value to a field of a basic kind.
+// Slices are filled from comma separated values.
+func setFromString(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setFromString(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFromString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
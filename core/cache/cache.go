@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Alexigbokwe/goNextCore/core/config"
 	"sync"
@@ -11,10 +12,16 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNotFound is returned by Get for missing or expired keys
+var ErrNotFound = errors.New("key not found")
+
 // Store defines the contract for caching
 type Store interface {
 	Get(ctx context.Context, key string, dest interface{}) error
 	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// Add stores value only when key is missing (or expired) and reports whether it
+	// did. It is atomic, so concurrent callers can use it as a once-only marker.
+	Add(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
 	Forget(ctx context.Context, key string) error
 	Flush(ctx context.Context) error
 }
@@ -31,7 +38,7 @@ func NewStore(cfg *config.Config) Store {
 			Addr:     addr,
 			Password: cfg.Redis.Password,
 		})
-		return &RedisStore{client: rdb}
+		return NewRedisStore(rdb)
 	}
 	return NewMemoryStore()
 }
@@ -53,31 +60,62 @@ func NewMemoryStore() *MemoryStore {
 func (m *MemoryStore) Get(ctx context.Context, key string, dest interface{}) error {
 	val, ok := m.items.Load(key)
 	if !ok {
-		return fmt.Errorf("key not found")
+		return ErrNotFound
 	}
 
-	it := val.(item)
-	if it.ExpiresAt > 0 && time.Now().UnixNano() > it.ExpiresAt {
-		m.items.Delete(key)
-		return fmt.Errorf("key expired")
+	it := val.(*item)
+	if it.expired() {
+		m.items.CompareAndDelete(key, it)
+		return fmt.Errorf("%w: key expired", ErrNotFound)
 	}
 
 	return json.Unmarshal(it.Value, dest)
 }
 
 func (m *MemoryStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	data, err := json.Marshal(value)
+	it, err := newItem(value, ttl)
 	if err != nil {
 		return err
 	}
+	m.items.Store(key, it)
+	return nil
+}
+
+func (m *MemoryStore) Add(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	it, err := newItem(value, ttl)
+	if err != nil {
+		return false, err
+	}
+	for {
+		existing, loaded := m.items.LoadOrStore(key, it)
+		if !loaded {
+			return true, nil
+		}
+		if !existing.(*item).expired() {
+			return false, nil
+		}
+		// Replace the expired entry unless another caller already did
+		if m.items.CompareAndSwap(key, existing, it) {
+			return true, nil
+		}
+	}
+}
+
+func newItem(value interface{}, ttl time.Duration) (*item, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
 
 	var exp int64
 	if ttl > 0 {
 		exp = time.Now().Add(ttl).UnixNano()
 	}
+	return &item{Value: data, ExpiresAt: exp}, nil
+}
 
-	m.items.Store(key, item{Value: data, ExpiresAt: exp})
-	return nil
+func (it *item) expired() bool {
+	return it.ExpiresAt > 0 && time.Now().UnixNano() > it.ExpiresAt
 }
 
 func (m *MemoryStore) Forget(ctx context.Context, key string) error {
@@ -95,8 +133,20 @@ type RedisStore struct {
 	client *redis.Client
 }
 
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Client returns the underlying go-redis client, letting other subsystems share the connection pool
+func (r *RedisStore) Client() *redis.Client {
+	return r.client
+}
+
 func (r *RedisStore) Get(ctx context.Context, key string, dest interface{}) error {
 	val, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
@@ -111,6 +161,14 @@ func (r *RedisStore) Set(ctx context.Context, key string, value interface{}, ttl
 	return r.client.Set(ctx, key, data, ttl).Err()
 }
 
+func (r *RedisStore) Add(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, data, ttl).Result()
+}
+
 func (r *RedisStore) Forget(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
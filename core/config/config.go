@@ -1,15 +1,55 @@
 package config
 
+import "time"
+
 type Config struct {
+	App      AppConfig
 	Server   ServerConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
 	Queue    QueueConfig
 	Mail     MailConfig
+	Cors     CorsConfig
+}
+
+type AppConfig struct {
+	Name  string `mapstructure:"APP_NAME"`
+	Env   string `mapstructure:"APP_ENV"`
+	Debug bool   `mapstructure:"APP_DEBUG"`
+	// ProblemDetails renders error responses as RFC 9457 problem details
+	// (application/problem+json) instead of the standard response envelope
+	ProblemDetails bool `mapstructure:"APP_PROBLEM_DETAILS"`
+	// GroupValidationErrors makes Bind report validation errors as a map of field
+	// path to messages instead of a list
+	GroupValidationErrors bool `mapstructure:"APP_GROUP_VALIDATION_ERRORS"`
+}
+
+// IsProduction reports whether the app runs in a production environment
+func (a AppConfig) IsProduction() bool {
+	return a.Env == "production" || a.Env == "prod"
 }
 
 type ServerConfig struct {
-	Port string `mapstructure:"SERVER_PORT"`
+	Host       string `mapstructure:"SERVER_HOST"`
+	Port       string `mapstructure:"SERVER_PORT"`
+	UnixSocket string `mapstructure:"SERVER_UNIX_SOCKET"`
+
+	TLSCertFile     string `mapstructure:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile      string `mapstructure:"SERVER_TLS_KEY_FILE"`
+	TLSClientCAFile string `mapstructure:"SERVER_TLS_CLIENT_CA_FILE"`
+	// TLSClientAuth is one of none, request, require, verify-if-given, require-and-verify.
+	// Defaults to require-and-verify when a client CA is configured.
+	TLSClientAuth string `mapstructure:"SERVER_TLS_CLIENT_AUTH"`
+}
+
+// Addr returns the TCP address the server listens on
+func (s ServerConfig) Addr() string {
+	return s.Host + ":" + s.Port
+}
+
+// TLSEnabled reports whether a certificate and key are configured
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
 }
 
 type DatabaseConfig struct {
@@ -18,6 +58,18 @@ type DatabaseConfig struct {
 	User     string `mapstructure:"DB_USER"`
 	Password string `mapstructure:"DB_PASSWORD"`
 	DBName   string `mapstructure:"DB_NAME"`
+	SSLMode  string `mapstructure:"DB_SSLMODE"`
+
+	// Pool tuning, zero values keep the pgx defaults
+	MaxConns          int32         `mapstructure:"DB_MAX_CONNS"`
+	MinConns          int32         `mapstructure:"DB_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `mapstructure:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `mapstructure:"DB_HEALTH_CHECK_PERIOD"`
+
+	// Startup retries when the database is not reachable yet
+	ConnectRetries int           `mapstructure:"DB_CONNECT_RETRIES"`
+	ConnectBackoff time.Duration `mapstructure:"DB_CONNECT_BACKOFF"`
 }
 
 type RedisConfig struct {
@@ -38,3 +90,13 @@ type MailConfig struct {
 	Password string `mapstructure:"MAIL_PASSWORD"`
 	From     string `mapstructure:"MAIL_FROM_ADDRESS"`
 }
+
+// CorsConfig lists comma separated values, e.g. CORS_ALLOW_ORIGINS=https://app.example.com,https://admin.example.com
+type CorsConfig struct {
+	AllowOrigins     string `mapstructure:"CORS_ALLOW_ORIGINS"`
+	AllowMethods     string `mapstructure:"CORS_ALLOW_METHODS"`
+	AllowHeaders     string `mapstructure:"CORS_ALLOW_HEADERS"`
+	ExposeHeaders    string `mapstructure:"CORS_EXPOSE_HEADERS"`
+	AllowCredentials bool   `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           int    `mapstructure:"CORS_MAX_AGE"`
+}
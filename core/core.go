@@ -2,50 +2,86 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
-	"runtime/debug"
 
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
 type App struct {
 	*fiber.App
+	Config *config.Config
+	// TLSConfig, when set, is used by Start instead of the configured certificate files
+	TLSConfig *tls.Config
+
+	filters       []ExceptionFilter
+	reporter      ErrorReporter
+	defaultRouted bool
 }
 
+// appLocalsKey is the ctx local holding the *App serving the request
+const appLocalsKey = "app"
+
 func NewApp() *App {
-	return &App{
-		App: fiber.New(fiber.Config{
-			ErrorHandler: func(c *fiber.Ctx, err error) error {
-				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-			},
-		}),
+	return NewAppWithConfig(&config.Config{})
+}
+
+// NewAppWithConfig creates an app whose behaviour (e.g. error verbosity) follows cfg
+func NewAppWithConfig(cfg *config.Config) *App {
+	if cfg == nil {
+		cfg = &config.Config{}
 	}
+	app := &App{Config: cfg}
+	app.App = fiber.New(fiber.Config{
+		ErrorHandler: app.handleError,
+	})
+	app.App.Use(func(c *fiber.Ctx) error {
+		c.Locals(appLocalsKey, app)
+		return c.Next()
+	})
+	app.App.Use(requestid.New(requestid.Config{ContextKey: RequestIDKey}))
+	app.App.Use(app.recoverPanics)
+	return app
+}
+
+// appOf returns the App serving c, or nil outside an App's middleware stack
+func appOf(c *fiber.Ctx) *App {
+	app, _ := c.Locals(appLocalsKey).(*App)
+	return app
+}
+
+// problemDetails reports whether error responses use RFC 9457 problem details
+func (a *App) problemDetails() bool {
+	return a != nil && a.Config != nil && a.Config.App.ProblemDetails
+}
+
+// groupValidationErrors reports whether Bind groups validation errors by field
+func (a *App) groupValidationErrors() bool {
+	return a != nil && a.Config != nil && a.Config.App.GroupValidationErrors
 }
 
 func (a *App) Listen(addr string) error {
-	a.App.Use(recover.New(recover.Config{
-		EnableStackTrace: true,
-		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
-			fmt.Printf("=== PANIC RECOVERED ===\n")
-			fmt.Printf("Panic: %v\n", e)
-			fmt.Printf("Path: %s\n", c.Path())
-			fmt.Printf("Method: %s\n", c.Method())
-			fmt.Printf("Headers: %+v\n", c.GetReqHeaders())
-
-			// Print stack trace
-			fmt.Printf("Stack trace: %s\n", debug.Stack())
-		},
-	}))
+	a.mountDefaultRoute()
+	return a.App.Listen(addr)
+}
+
+// mountDefaultRoute registers the welcome route once, after the user routes
+func (a *App) mountDefaultRoute() {
+	if a.defaultRouted {
+		return
+	}
+	a.defaultRouted = true
 
 	// Default GET route
 	a.App.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Welcome to GoNext framework")
 	})
-
-	return a.App.Listen(addr)
 }
 
 // Called when a module is initialized.
@@ -91,29 +127,31 @@ func (app *App) ShutdownModules(modules []Module) {
 	}
 }
 
+// ConnectToDataBase opens a single pool for connectionString.
+//
+// Deprecated: use the database package (database.Connect or database.DatabaseModule),
+// which reads config.DatabaseConfig, retries on startup and supports named connections.
 func (app *App) ConnectToDataBase(connectionString string, databaseName string) (*pgxpool.Pool, context.Context, error) {
-	var err error
 	ctx := context.Background()
 	pool, err := pgxpool.New(ctx, connectionString)
 	if err != nil {
-		log.Printf("Unable to connect to timeline ms database: %v", err)
-		return nil, nil, fmt.Errorf("unable to connect to timeline ms database: %w", err)
+		logger.Log.Error("Unable to connect to database", zap.String("database", databaseName), zap.Error(err))
+		return nil, nil, fmt.Errorf("unable to connect to %s database: %w", databaseName, err)
 	}
 
 	if err = pool.Ping(ctx); err != nil {
-		log.Printf("Unable to ping timeline ms database: %v", err)
-		return nil, nil, fmt.Errorf("unable to ping timeline ms database: %w", err)
+		pool.Close()
+		logger.Log.Error("Unable to ping database", zap.String("database", databaseName), zap.Error(err))
+		return nil, nil, fmt.Errorf("unable to ping %s database: %w", databaseName, err)
 	}
 
-	fmt.Println("Connected to timeline ms database successfully!")
+	logger.Log.Info("Connected to database", zap.String("database", databaseName))
 	return pool, ctx, nil
 }
 
 func (app *App) DisconnectFromDatabase(dbPool *pgxpool.Pool) {
 	if dbPool != nil {
 		dbPool.Close()
-		fmt.Println("Timeline database connection closed successfully.")
-	} else {
-		fmt.Println("No Timeline database connection to close.")
+		logger.Log.Info("Database connection closed")
 	}
 }
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Well-known connection names
+const (
+	Primary   = "primary"
+	Replica   = "replica"
+	Analytics = "analytics"
+)
+
+const (
+	defaultConnectRetries = 5
+	defaultConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff     = 10 * time.Second
+)
+
+// DSN builds a postgres connection string from the database configuration
+func DSN(cfg config.DatabaseConfig) string {
+	host := cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := cfg.Port
+	if port == "" {
+		port = "5432"
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/" + cfg.DBName,
+	}
+	if cfg.SSLMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {cfg.SSLMode}}.Encode()
+	}
+	return dsn.String()
+}
+
+// PoolConfig parses the DSN and applies the pool tuning options
+func PoolConfig(cfg config.DatabaseConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(DSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	return poolConfig, nil
+}
+
+// Connect opens and pings a pool, retrying with exponential backoff while the database is unreachable
+func Connect(ctx context.Context, name string, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := PoolConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := cfg.ConnectRetries
+	if retries <= 0 {
+		retries = defaultConnectRetries
+	}
+	backoff := cfg.ConnectBackoff
+	if backoff <= 0 {
+		backoff = defaultConnectBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err == nil {
+			if err = pool.Ping(ctx); err == nil {
+				logger.Log.Info("Connected to database",
+					zap.String("connection", name),
+					zap.String("database", cfg.DBName),
+				)
+				return pool, nil
+			}
+			pool.Close()
+		}
+
+		if attempt > retries {
+			return nil, fmt.Errorf("unable to connect to %s database after %d attempts: %w", name, attempt, err)
+		}
+
+		logger.Log.Warn("Database not reachable, retrying",
+			zap.String("connection", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+// Manager keeps track of the named connection pools of an application
+type Manager struct {
+	pools map[string]*pgxpool.Pool
+	mu    sync.RWMutex
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		pools: make(map[string]*pgxpool.Pool),
+	}
+}
+
+// Connect opens the named connection and keeps it for later lookups
+func (m *Manager) Connect(ctx context.Context, name string, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	pool, err := Connect(ctx, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.Add(name, pool)
+	return pool, nil
+}
+
+// Add registers an already opened pool under name
+func (m *Manager) Add(name string, pool *pgxpool.Pool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools[name] = pool
+}
+
+// Pool returns the named connection pool
+func (m *Manager) Pool(name string) (*pgxpool.Pool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pool, ok := m.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("no database connection named %s", name)
+	}
+	return pool, nil
+}
+
+// Names returns the names of all open connections, sorted
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.pools))
+	for name := range m.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every pool
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, pool := range m.pools {
+		pool.Close()
+		logger.Log.Info("Database connection closed", zap.String("connection", name))
+	}
+	m.pools = make(map[string]*pgxpool.Pool)
+}
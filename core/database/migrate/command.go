@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Usage describes the arguments accepted by RunCommand
+const Usage = `usage: migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [N]        roll back the last N migrations (default 1)
+  status          list migrations and whether they are applied
+  redo            roll back and re-apply the last migration
+  create <name>   create a new up/down migration pair in the migrations directory`
+
+// RunCommand executes a migration command from CLI arguments, e.g. os.Args[2:]
+// when an application exposes `app migrate ...`
+func (m *Migrator) RunCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d migration(s)\n", count)
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			parsed, err := strconv.Atoi(args[1])
+			if err != nil || parsed < 1 {
+				return fmt.Errorf("invalid number of migrations to roll back: %q", args[1])
+			}
+			n = parsed
+		}
+		count, err := m.Down(ctx, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Rolled back %d migration(s)\n", count)
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, state)
+		}
+		return w.Flush()
+
+	case "redo":
+		if err := m.Redo(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Re-applied last migration")
+
+	case "create":
+		if len(args) < 2 {
+			return errors.New("usage: migrate create <name>")
+		}
+		if m.Dir == "" {
+			return errors.New("migrations directory is not configured")
+		}
+		files, err := Create(m.Dir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintf(out, "Created %s\n", file)
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], Usage)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// DefaultTable is the table that records applied migrations
+const DefaultTable = "schema_migrations"
+
+// Migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a versioned pair of up/down SQL scripts
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads the migrations at the root of fsys, ordered by version.
+// Use os.DirFS for a directory or fs.Sub on an embed.FS.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d (%s, %s)", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Create writes an empty up/down pair for name into dir, versioned by the current UTC time
+func Create(dir string, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("-- %s migration for %s\n", direction, name)), 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// Migrator applies and rolls back migrations against a Postgres pool
+type Migrator struct {
+	pool *pgxpool.Pool
+	fsys fs.FS
+	// Table records applied versions, optionally schema qualified
+	Table string
+	// Dir is where the create command writes new migration files
+	Dir string
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) *Migrator {
+	return &Migrator{
+		pool:  pool,
+		fsys:  fsys,
+		Table: DefaultTable,
+	}
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		count, err = m.up(ctx, conn, -1)
+		return err
+	})
+	return count, err
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		count, err = m.down(ctx, conn, n)
+		return err
+	})
+	return count, err
+}
+
+// Redo rolls back the last applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		count, err := m.down(ctx, conn, 1)
+		if err != nil || count == 0 {
+			return err
+		}
+		_, err = m.up(ctx, conn, 1)
+		return err
+	})
+}
+
+// Status lists every known migration along with whether it has been applied. It
+// only reads, so it neither waits for a running migration nor creates the table.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Load(m.fsys)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.pool)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+		applied, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+// up applies at most limit pending migrations, all of them when limit is negative
+func (m *Migrator) up(ctx context.Context, conn *pgxpool.Conn, limit int) (int, error) {
+	migrations, err := Load(m.fsys)
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if limit >= 0 && count >= limit {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO "+m.table()+" (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		logger.Log.Info("Migration applied", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		count++
+	}
+	return count, nil
+}
+
+// down rolls back the n most recently applied migrations
+func (m *Migrator) down(ctx context.Context, conn *pgxpool.Conn, n int) (int, error) {
+	migrations, err := Load(m.fsys)
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < n; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if strings.TrimSpace(migration.Down) != "" {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec(ctx, "DELETE FROM "+m.table()+" WHERE version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		logger.Log.Info("Migration rolled back", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		count++
+	}
+	return count, nil
+}
+
+// undefinedTable is the SQLSTATE of a missing migrations table
+const undefinedTable = "42P01"
+
+// querier is satisfied by pool connections and the pool itself
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn querier) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM "+m.table())
+	if err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock,
+// so concurrent instances never apply the same migration twice
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire migration connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockKey()); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", m.lockKey())
+
+	_, err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+m.table()+` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) table() string {
+	return pgx.Identifier(strings.Split(m.Table, ".")).Sanitize()
+}
+
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + m.Table))
+	return int64(h.Sum64())
+}
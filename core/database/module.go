@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/database/migrate"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Token returns the container token under which the named pool is bound,
+// e.g. `inject:"database.replica"`
+func Token(name string) string {
+	return "database." + name
+}
+
+// DatabaseModule connects the configured databases on init, binds each pool in the
+// container and closes them on shutdown. The primary pool is also registered by
+// type, so `inject:"type"` on a *pgxpool.Pool field resolves to it.
+type DatabaseModule struct {
+	Connections map[string]config.DatabaseConfig
+	Manager     *Manager
+
+	// Migrations, when set with MigrateOnInit, are applied to the primary
+	// connection during OnModuleInit
+	Migrations    fs.FS
+	MigrateOnInit bool
+}
+
+// NewDatabaseModule creates a module whose primary connection uses cfg.Database
+func NewDatabaseModule(cfg *config.Config) *DatabaseModule {
+	return &DatabaseModule{
+		Connections: map[string]config.DatabaseConfig{Primary: cfg.Database},
+		Manager:     NewManager(),
+	}
+}
+
+// WithMigrations runs the pending migrations of fsys on the primary connection at startup
+func (m *DatabaseModule) WithMigrations(fsys fs.FS) *DatabaseModule {
+	m.Migrations = fsys
+	m.MigrateOnInit = true
+	return m
+}
+
+// WithConnection adds another named connection (e.g. Replica, Analytics)
+func (m *DatabaseModule) WithConnection(name string, cfg config.DatabaseConfig) *DatabaseModule {
+	m.Connections[name] = cfg
+	return m
+}
+
+func (m *DatabaseModule) OnModuleInit() error {
+	names := make([]string, 0, len(m.Connections))
+	for name := range m.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx := context.Background()
+	for _, name := range names {
+		if _, err := m.Manager.Connect(ctx, name, m.Connections[name]); err != nil {
+			m.Manager.Close()
+			return err
+		}
+	}
+
+	if m.MigrateOnInit && m.Migrations != nil {
+		pool, err := m.Manager.Pool(Primary)
+		if err != nil {
+			m.Manager.Close()
+			return err
+		}
+		if _, err := migrate.New(pool, m.Migrations).Up(ctx); err != nil {
+			m.Manager.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DatabaseModule) Register(container *core.Container) {
+	container.Register(m.Manager)
+	for _, name := range m.Manager.Names() {
+		pool, _ := m.Manager.Pool(name)
+		container.Bind(Token(name), pool)
+		if name == Primary {
+			container.Register(pool)
+		}
+	}
+}
+
+func (m *DatabaseModule) MountRoutes(router fiber.Router) {}
+
+func (m *DatabaseModule) OnModuleDestroy() error {
+	m.Manager.Close()
+	return nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+)
+
+// Paginate returns an offset page of entities along with the total count
+func (r *Repository[T]) Paginate(ctx context.Context, params core.PageParams, where ...Condition) (core.Paginated[*T], error) {
+	if err := normalizeLimit(&params); err != nil {
+		return core.Paginated[*T]{}, err
+	}
+	opts := FindOptions{
+		Where:  where,
+		Sort:   toSorts(params.Sort),
+		Limit:  params.Limit,
+		Offset: params.Offset(),
+	}
+
+	items, err := r.FindMany(ctx, opts)
+	if err != nil {
+		return core.Paginated[*T]{}, err
+	}
+	total, err := r.Count(ctx, opts)
+	if err != nil {
+		return core.Paginated[*T]{}, err
+	}
+	return core.NewPage(items, params, total), nil
+}
+
+// PaginateCursor returns a keyset page. Results are ordered by the first sort field
+// (the primary key when none is given) with the primary key as tie-breaker, and the
+// cursor carries the position of the last item.
+func (r *Repository[T]) PaginateCursor(ctx context.Context, params core.PageParams, codec *core.CursorCodec, where ...Condition) (core.Paginated[*T], error) {
+	if r.meta.pk == nil {
+		return core.Paginated[*T]{}, fmt.Errorf("%s has no primary key column", r.Table)
+	}
+	if err := normalizeLimit(&params); err != nil {
+		return core.Paginated[*T]{}, err
+	}
+
+	order := core.SortField{Field: r.meta.pk.name}
+	if len(params.Sort) > 0 {
+		order = params.Sort[0]
+	}
+	keys := []string{order.Field}
+	if order.Field != r.meta.pk.name {
+		keys = append(keys, r.meta.pk.name)
+	}
+
+	sorts := make([]Sort, len(keys))
+	for i, key := range keys {
+		sorts[i] = Sort{Column: key, Desc: order.Desc}
+	}
+
+	if params.Cursor != "" {
+		position, err := codec.Decode(params.Cursor)
+		if err != nil {
+			return core.Paginated[*T]{}, core.BadRequest("Invalid cursor").WithCause(err)
+		}
+		condition, err := keysetCondition(keys, position, order.Desc)
+		if err != nil {
+			return core.Paginated[*T]{}, core.BadRequest("Invalid cursor").WithCause(err)
+		}
+		where = append(where, condition)
+	}
+
+	// Fetch one extra row to know whether another page follows
+	items, err := r.FindMany(ctx, FindOptions{Where: where, Sort: sorts, Limit: params.Limit + 1})
+	if err != nil {
+		return core.Paginated[*T]{}, err
+	}
+
+	nextCursor := ""
+	if len(items) > params.Limit {
+		items = items[:params.Limit]
+		last := reflect.ValueOf(items[len(items)-1]).Elem()
+		position := make(map[string]any, len(keys))
+		for _, key := range keys {
+			c := r.meta.column(key)
+			if c == nil {
+				return core.Paginated[*T]{}, fmt.Errorf("cannot paginate %s by unknown column %q", r.Table, key)
+			}
+			position[key] = last.FieldByIndex(c.index).Interface()
+		}
+		if nextCursor, err = codec.Encode(position); err != nil {
+			return core.Paginated[*T]{}, err
+		}
+	}
+
+	return core.NewCursorPage(items, params.Limit, nextCursor), nil
+}
+
+// normalizeLimit applies the default page size to an unset limit, as for
+// PageParams built directly rather than by core.ParsePageParams
+func normalizeLimit(params *core.PageParams) error {
+	if params.Limit < 0 {
+		return core.BadRequest("limit must be a positive integer")
+	}
+	if params.Limit == 0 {
+		params.Limit = core.DefaultPaginationConfig.DefaultLimit
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	return nil
+}
+
+// keysetCondition builds a row comparison such as ("created_at", "id") > ($1, $2)
+func keysetCondition(keys []string, position map[string]any, desc bool) (Condition, error) {
+	args := make([]any, len(keys))
+	placeholders := make([]string, len(keys))
+	for i, key := range keys {
+		value, ok := position[key]
+		if !ok {
+			return Condition{}, fmt.Errorf("cursor is missing %s", key)
+		}
+		if number, ok := value.(json.Number); ok {
+			if integer, err := number.Int64(); err == nil {
+				value = integer
+			} else if float, err := number.Float64(); err == nil {
+				value = float
+			}
+		}
+		args[i] = value
+		placeholders[i] = "?"
+	}
+
+	operator := ">"
+	if desc {
+		operator = "<"
+	}
+	return Expr(fmt.Sprintf("(%s) %s (%s)", identList(keys), operator, strings.Join(placeholders, ", ")), args...), nil
+}
+
+func toSorts(fields []core.SortField) []Sort {
+	sorts := make([]Sort, len(fields))
+	for i, field := range fields {
+		sorts[i] = Sort{Column: field.Field, Desc: field.Desc}
+	}
+	return sorts
+}
@@ -0,0 +1,403 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Condition is a SQL boolean expression using ? placeholders, numbered when the query
+// is built. Quoted strings and identifiers are left untouched and ?? writes a literal
+// ?, e.g. for the jsonb operator: Expr("data ?? ?", "key").
+type Condition struct {
+	SQL  string
+	Args []any
+}
+
+// Expr creates a condition from raw SQL, e.g. Expr("age > ? AND age < ?", 18, 65)
+func Expr(sql string, args ...any) Condition {
+	return Condition{SQL: sql, Args: args}
+}
+
+func Eq(column string, value any) Condition {
+	return compare(column, "=", value)
+}
+
+func Neq(column string, value any) Condition {
+	return compare(column, "<>", value)
+}
+
+func Gt(column string, value any) Condition {
+	return compare(column, ">", value)
+}
+
+func Gte(column string, value any) Condition {
+	return compare(column, ">=", value)
+}
+
+func Lt(column string, value any) Condition {
+	return compare(column, "<", value)
+}
+
+func Lte(column string, value any) Condition {
+	return compare(column, "<=", value)
+}
+
+func Like(column string, pattern string) Condition {
+	return compare(column, "LIKE", pattern)
+}
+
+func ILike(column string, pattern string) Condition {
+	return compare(column, "ILIKE", pattern)
+}
+
+// In matches column against a list of values using = ANY($n)
+func In[V any](column string, values []V) Condition {
+	return Condition{SQL: Ident(column) + " = ANY(?)", Args: []any{values}}
+}
+
+func IsNull(column string) Condition {
+	return Condition{SQL: Ident(column) + " IS NULL"}
+}
+
+func IsNotNull(column string) Condition {
+	return Condition{SQL: Ident(column) + " IS NOT NULL"}
+}
+
+// And joins conditions with AND
+func And(conditions ...Condition) Condition {
+	return join(" AND ", conditions)
+}
+
+// Or joins conditions with OR
+func Or(conditions ...Condition) Condition {
+	return join(" OR ", conditions)
+}
+
+func Not(condition Condition) Condition {
+	return Condition{SQL: "NOT (" + condition.SQL + ")", Args: condition.Args}
+}
+
+func compare(column string, operator string, value any) Condition {
+	return Condition{SQL: Ident(column) + " " + operator + " ?", Args: []any{value}}
+}
+
+func join(separator string, conditions []Condition) Condition {
+	parts := make([]string, 0, len(conditions))
+	var args []any
+	for _, condition := range conditions {
+		if condition.SQL == "" {
+			continue
+		}
+		parts = append(parts, condition.SQL)
+		args = append(args, condition.Args...)
+	}
+	if len(parts) > 1 {
+		for i, part := range parts {
+			parts[i] = "(" + part + ")"
+		}
+	}
+	return Condition{SQL: strings.Join(parts, separator), Args: args}
+}
+
+// Ident quotes a possibly schema-qualified identifier, e.g. public.users -> "public"."users"
+func Ident(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
+
+func identList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = Ident(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Sort orders results by a column
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+func (s Sort) sql() string {
+	if s.Desc {
+		return Ident(s.Column) + " DESC"
+	}
+	return Ident(s.Column) + " ASC"
+}
+
+// queryBuffer accumulates SQL text and numbers ? placeholders as $1, $2, ...
+// outside quoted strings and identifiers
+type queryBuffer struct {
+	sql  strings.Builder
+	args []any
+}
+
+func (b *queryBuffer) write(s string) {
+	b.sql.WriteString(s)
+}
+
+func (b *queryBuffer) writeCondition(condition Condition) {
+	sql, argIndex := condition.SQL, 0
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case sql[i] == '\'' || sql[i] == '"' || sql[i] == '$':
+			end := quotedEnd(sql, i)
+			b.sql.WriteString(sql[i:end])
+			i = end - 1
+		case sql[i] == '?' && i+1 < len(sql) && sql[i+1] == '?':
+			b.sql.WriteByte('?')
+			i++
+		case sql[i] == '?' && argIndex < len(condition.Args):
+			b.args = append(b.args, condition.Args[argIndex])
+			argIndex++
+			b.sql.WriteString("$" + strconv.Itoa(len(b.args)))
+		default:
+			b.sql.WriteByte(sql[i])
+		}
+	}
+}
+
+// quotedEnd returns the index after the quoted string, quoted identifier or
+// dollar-quoted string starting at start, or start+1 for a lone $
+func quotedEnd(sql string, start int) int {
+	quote := sql[start]
+	if quote == '$' {
+		tag := dollarTag(sql, start)
+		if tag == "" {
+			return start + 1
+		}
+		end := strings.Index(sql[start+len(tag):], tag)
+		if end < 0 {
+			return len(sql)
+		}
+		return start + len(tag) + end + len(tag)
+	}
+
+	// E'...' strings escape quotes with backslashes
+	backslashes := quote == '\'' && start > 0 && (sql[start-1] == 'E' || sql[start-1] == 'e')
+	for i := start + 1; i < len(sql); i++ {
+		switch {
+		case backslashes && sql[i] == '\\':
+			i++
+		case sql[i] == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i++
+		case sql[i] == quote:
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag returns the opening $tag$ of a dollar-quoted string at start, or ""
+// when the $ starts something else, e.g. a $1 parameter
+func dollarTag(sql string, start int) string {
+	for i := start + 1; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '$':
+			return sql[start : i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && i > start+1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func (b *queryBuffer) placeholder(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuffer) writeWhere(conditions []Condition) {
+	where := And(conditions...)
+	if where.SQL == "" {
+		return
+	}
+	b.write(" WHERE ")
+	b.writeCondition(where)
+}
+
+// SelectBuilder builds a parameterized SELECT statement
+type SelectBuilder struct {
+	table   string
+	columns []string
+	count   bool
+	where   []Condition
+	orderBy []Sort
+	limit   int
+	offset  int
+}
+
+// Select starts a SELECT over table; no columns means *
+func Select(table string, columns ...string) *SelectBuilder {
+	return &SelectBuilder{table: table, columns: columns}
+}
+
+func (q *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	q.where = append(q.where, conditions...)
+	return q
+}
+
+func (q *SelectBuilder) OrderBy(sorts ...Sort) *SelectBuilder {
+	q.orderBy = append(q.orderBy, sorts...)
+	return q
+}
+
+func (q *SelectBuilder) Limit(limit int) *SelectBuilder {
+	q.limit = limit
+	return q
+}
+
+func (q *SelectBuilder) Offset(offset int) *SelectBuilder {
+	q.offset = offset
+	return q
+}
+
+// Count returns a SELECT count(*) over the same table and filters
+func (q *SelectBuilder) Count() *SelectBuilder {
+	return &SelectBuilder{table: q.table, count: true, where: q.where}
+}
+
+func (q *SelectBuilder) Build() (string, []any) {
+	var b queryBuffer
+	b.write("SELECT ")
+	switch {
+	case q.count:
+		b.write("count(*)")
+	case len(q.columns) == 0:
+		b.write("*")
+	default:
+		b.write(identList(q.columns))
+	}
+	b.write(" FROM " + Ident(q.table))
+	b.writeWhere(q.where)
+
+	if len(q.orderBy) > 0 {
+		orders := make([]string, len(q.orderBy))
+		for i, sort := range q.orderBy {
+			orders[i] = sort.sql()
+		}
+		b.write(" ORDER BY " + strings.Join(orders, ", "))
+	}
+	if q.limit > 0 {
+		b.write(" LIMIT " + b.placeholder(q.limit))
+	}
+	if q.offset > 0 {
+		b.write(" OFFSET " + b.placeholder(q.offset))
+	}
+	return b.sql.String(), b.args
+}
+
+// InsertBuilder builds a parameterized INSERT statement
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]any
+	returning []string
+}
+
+func Insert(table string, columns ...string) *InsertBuilder {
+	return &InsertBuilder{table: table, columns: columns}
+}
+
+// Values adds a row; the values must follow the column order
+func (q *InsertBuilder) Values(values ...any) *InsertBuilder {
+	q.rows = append(q.rows, values)
+	return q
+}
+
+func (q *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	q.returning = columns
+	return q
+}
+
+func (q *InsertBuilder) Build() (string, []any) {
+	var b queryBuffer
+	b.write(fmt.Sprintf("INSERT INTO %s (%s) VALUES ", Ident(q.table), identList(q.columns)))
+	for i, row := range q.rows {
+		if i > 0 {
+			b.write(", ")
+		}
+		placeholders := make([]string, len(row))
+		for j, value := range row {
+			placeholders[j] = b.placeholder(value)
+		}
+		b.write("(" + strings.Join(placeholders, ", ") + ")")
+	}
+	if len(q.returning) > 0 {
+		b.write(" RETURNING " + identList(q.returning))
+	}
+	return b.sql.String(), b.args
+}
+
+// UpdateBuilder builds a parameterized UPDATE statement
+type UpdateBuilder struct {
+	table     string
+	columns   []string
+	values    []any
+	where     []Condition
+	returning []string
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+func (q *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	q.columns = append(q.columns, column)
+	q.values = append(q.values, value)
+	return q
+}
+
+func (q *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	q.where = append(q.where, conditions...)
+	return q
+}
+
+func (q *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	q.returning = columns
+	return q
+}
+
+func (q *UpdateBuilder) Build() (string, []any) {
+	var b queryBuffer
+	b.write("UPDATE " + Ident(q.table) + " SET ")
+	for i, column := range q.columns {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.write(Ident(column) + " = " + b.placeholder(q.values[i]))
+	}
+	b.writeWhere(q.where)
+	if len(q.returning) > 0 {
+		b.write(" RETURNING " + identList(q.returning))
+	}
+	return b.sql.String(), b.args
+}
+
+// DeleteBuilder builds a parameterized DELETE statement
+type DeleteBuilder struct {
+	table string
+	where []Condition
+}
+
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+func (q *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	q.where = append(q.where, conditions...)
+	return q
+}
+
+func (q *DeleteBuilder) Build() (string, []any) {
+	var b queryBuffer
+	b.write("DELETE FROM " + Ident(q.table))
+	b.writeWhere(q.where)
+	return b.sql.String(), b.args
+}
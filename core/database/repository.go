@@ -0,0 +1,332 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned when no row matches the requested entity
+var ErrNotFound = errors.New("record not found")
+
+// column describes a struct field mapped through a tag such as `db:"id,pk,auto"`.
+// Options: pk marks the primary key, auto marks database generated values
+// (excluded from writes and read back via RETURNING), softdelete marks the
+// nullable timestamp used for soft deletes.
+type column struct {
+	name       string
+	index      []int
+	pk         bool
+	auto       bool
+	softDelete bool
+}
+
+type entityMeta struct {
+	columns    []column
+	pk         *column
+	softDelete *column
+}
+
+var entityMetaCache sync.Map
+
+func metaFor(t reflect.Type) *entityMeta {
+	if cached, ok := entityMetaCache.Load(t); ok {
+		return cached.(*entityMeta)
+	}
+
+	meta := &entityMeta{}
+	collectColumns(t, nil, meta)
+	for i := range meta.columns {
+		if meta.columns[i].pk {
+			meta.pk = &meta.columns[i]
+		}
+		if meta.columns[i].softDelete {
+			meta.softDelete = &meta.columns[i]
+		}
+	}
+
+	entityMetaCache.Store(t, meta)
+	return meta
+}
+
+func collectColumns(t reflect.Type, parent []int, meta *entityMeta) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		tag, ok := field.Tag.Lookup("db")
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				collectColumns(field.Type, index, meta)
+			}
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		col := column{name: parts[0], index: index}
+		for _, option := range parts[1:] {
+			switch option {
+			case "pk":
+				col.pk = true
+			case "auto":
+				col.auto = true
+			case "softdelete":
+				col.softDelete = true
+			}
+		}
+		meta.columns = append(meta.columns, col)
+	}
+}
+
+func (m *entityMeta) names(include func(c column) bool) []string {
+	var names []string
+	for _, c := range m.columns {
+		if include(c) {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+func (m *entityMeta) column(name string) *column {
+	for i := range m.columns {
+		if m.columns[i].name == name {
+			return &m.columns[i]
+		}
+	}
+	return nil
+}
+
+func (m *entityMeta) has(name string) bool {
+	return m.column(name) != nil
+}
+
+// FindOptions filters, sorts and pages FindMany results
+type FindOptions struct {
+	Where       []Condition
+	Sort        []Sort
+	Limit       int
+	Offset      int
+	WithDeleted bool
+}
+
+// Repository provides CRUD operations for a struct mapped with db tags.
+// Queries run on the ambient transaction of the context when there is one.
+type Repository[T any] struct {
+	db    Querier
+	Table string
+	meta  *entityMeta
+}
+
+func NewRepository[T any](db Querier, table string) *Repository[T] {
+	var zero T
+	return &Repository[T]{
+		db:    db,
+		Table: table,
+		meta:  metaFor(reflect.TypeOf(zero)),
+	}
+}
+
+// Columns returns the mapped column names in struct order
+func (r *Repository[T]) Columns() []string {
+	return r.meta.names(func(c column) bool { return true })
+}
+
+func (r *Repository[T]) querier(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// Query returns the SELECT used by FindMany, useful for inspecting or extending the SQL
+func (r *Repository[T]) Query(opts FindOptions) (*SelectBuilder, error) {
+	for _, sort := range opts.Sort {
+		if !r.meta.has(sort.Column) {
+			return nil, fmt.Errorf("cannot sort %s by unknown column %q", r.Table, sort.Column)
+		}
+	}
+
+	query := Select(r.Table, r.Columns()...).
+		Where(opts.Where...).
+		OrderBy(opts.Sort...).
+		Limit(opts.Limit).
+		Offset(opts.Offset)
+	if r.meta.softDelete != nil && !opts.WithDeleted {
+		query.Where(IsNull(r.meta.softDelete.name))
+	}
+	return query, nil
+}
+
+func (r *Repository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	if r.meta.pk == nil {
+		return nil, fmt.Errorf("%s has no primary key column", r.Table)
+	}
+	return r.FindOne(ctx, Eq(r.meta.pk.name, id))
+}
+
+// FindOne returns the first entity matching all conditions, or ErrNotFound
+func (r *Repository[T]) FindOne(ctx context.Context, conditions ...Condition) (*T, error) {
+	items, err := r.FindMany(ctx, FindOptions{Where: conditions, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items[0], nil
+}
+
+func (r *Repository[T]) FindMany(ctx context.Context, opts FindOptions) ([]*T, error) {
+	query, err := r.Query(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args := query.Build()
+	rows, err := r.querier(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[T])
+}
+
+// Count returns the number of rows matching the filters of opts
+func (r *Repository[T]) Count(ctx context.Context, opts FindOptions) (int64, error) {
+	query, err := r.Query(FindOptions{Where: opts.Where, WithDeleted: opts.WithDeleted})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	sql, args := query.Count().Build()
+	err = r.querier(ctx).QueryRow(ctx, sql, args...).Scan(&count)
+	return count, err
+}
+
+// Insert stores entity and reads database generated (auto) columns back into it
+func (r *Repository[T]) Insert(ctx context.Context, entity *T) error {
+	value := reflect.ValueOf(entity).Elem()
+	query := Insert(r.Table, r.writableColumns()...).Values(r.writableValues(value)...)
+
+	returning := r.meta.names(func(c column) bool { return c.auto })
+	if len(returning) == 0 {
+		sql, args := query.Build()
+		_, err := r.querier(ctx).Exec(ctx, sql, args...)
+		return err
+	}
+
+	sql, args := query.Returning(returning...).Build()
+	return r.querier(ctx).QueryRow(ctx, sql, args...).Scan(r.autoFields(value)...)
+}
+
+// InsertBatch bulk loads entities with the COPY protocol
+func (r *Repository[T]) InsertBatch(ctx context.Context, entities []*T) (int64, error) {
+	rows := make([][]any, len(entities))
+	for i, entity := range entities {
+		rows[i] = r.writableValues(reflect.ValueOf(entity).Elem())
+	}
+	return r.querier(ctx).CopyFrom(ctx, pgx.Identifier(strings.Split(r.Table, ".")), r.writableColumns(), pgx.CopyFromRows(rows))
+}
+
+// Update writes every non generated column of entity, matched by primary key.
+// The soft delete column is left to Delete and Restore, and soft deleted rows are
+// not updated.
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	if r.meta.pk == nil {
+		return fmt.Errorf("%s has no primary key column", r.Table)
+	}
+
+	value := reflect.ValueOf(entity).Elem()
+	query := Update(r.Table)
+	for _, c := range r.meta.columns {
+		if !c.pk && !c.auto && !c.softDelete {
+			query.Set(c.name, value.FieldByIndex(c.index).Interface())
+		}
+	}
+	where := []Condition{Eq(r.meta.pk.name, value.FieldByIndex(r.meta.pk.index).Interface())}
+	if r.meta.softDelete != nil {
+		where = append(where, IsNull(r.meta.softDelete.name))
+	}
+	query.Where(where...)
+
+	return r.exec(ctx, query)
+}
+
+// Delete soft deletes the row when the entity has a softdelete column, otherwise removes it
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	if r.meta.softDelete == nil {
+		return r.ForceDelete(ctx, id)
+	}
+	if r.meta.pk == nil {
+		return fmt.Errorf("%s has no primary key column", r.Table)
+	}
+
+	return r.exec(ctx, Update(r.Table).
+		Set(r.meta.softDelete.name, time.Now()).
+		Where(Eq(r.meta.pk.name, id), IsNull(r.meta.softDelete.name)))
+}
+
+// Restore clears the soft delete marker of a row
+func (r *Repository[T]) Restore(ctx context.Context, id any) error {
+	if r.meta.softDelete == nil || r.meta.pk == nil {
+		return fmt.Errorf("%s does not support soft deletes", r.Table)
+	}
+	return r.exec(ctx, Update(r.Table).
+		Set(r.meta.softDelete.name, nil).
+		Where(Eq(r.meta.pk.name, id)))
+}
+
+// ForceDelete permanently removes the row, bypassing soft deletes
+func (r *Repository[T]) ForceDelete(ctx context.Context, id any) error {
+	if r.meta.pk == nil {
+		return fmt.Errorf("%s has no primary key column", r.Table)
+	}
+	return r.exec(ctx, Delete(r.Table).Where(Eq(r.meta.pk.name, id)))
+}
+
+// exec runs a write statement and reports ErrNotFound when no row was affected
+func (r *Repository[T]) exec(ctx context.Context, query interface{ Build() (string, []any) }) error {
+	sql, args := query.Build()
+	tag, err := r.querier(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) writableColumns() []string {
+	return r.meta.names(func(c column) bool { return !c.auto })
+}
+
+func (r *Repository[T]) writableValues(value reflect.Value) []any {
+	var values []any
+	for _, c := range r.meta.columns {
+		if !c.auto {
+			values = append(values, value.FieldByIndex(c.index).Interface())
+		}
+	}
+	return values
+}
+
+func (r *Repository[T]) autoFields(value reflect.Value) []any {
+	var fields []any
+	for _, c := range r.meta.columns {
+		if c.auto {
+			fields = append(fields, value.FieldByIndex(c.index).Addr().Interface())
+		}
+	}
+	return fields
+}
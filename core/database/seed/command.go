@@ -0,0 +1,44 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Usage describes the arguments accepted by RunCommand
+const Usage = `usage: seed [command]
+
+commands:
+  (none)          run every registered seeder
+  run [name...]   run the named seeders and their dependencies
+  list            list registered seeders in execution order`
+
+// RunCommand executes a seeding command from CLI arguments, e.g. os.Args[2:]
+// when an application exposes `app seed ...`
+func (r *Runner) RunCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		args = []string{"run"}
+	}
+
+	switch args[0] {
+	case "run":
+		if err := r.Run(ctx, args[1:]...); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Seeding completed")
+
+	case "list":
+		plan, err := r.registry.Plan()
+		if err != nil {
+			return err
+		}
+		for _, seeder := range plan {
+			fmt.Fprintln(out, seeder.Name())
+		}
+
+	default:
+		return fmt.Errorf("unknown seed command %q\n%s", args[0], Usage)
+	}
+	return nil
+}
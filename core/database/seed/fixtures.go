@@ -0,0 +1,108 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFixtures inserts the rows described by YAML or JSON fixture files. Each file maps
+// table names to lists of rows; tables are loaded in the order they appear:
+//
+//	roles:
+//	  - {id: 1, name: admin}
+//	users:
+//	  - {id: 1, email: admin@example.com, role_id: 1}
+func LoadFixtures(ctx context.Context, db database.Querier, fsys fs.FS, files ...string) error {
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("read fixture %s: %w", file, err)
+		}
+		if err := loadFixture(ctx, db, content); err != nil {
+			return fmt.Errorf("load fixture %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// loadFixture parses a document (JSON is valid YAML) keeping the table order of the file
+func loadFixture(ctx context.Context, db database.Querier, content []byte) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return err
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	tables := document.Content[0]
+	if tables.Kind != yaml.MappingNode {
+		return fmt.Errorf("fixture must map table names to rows")
+	}
+
+	for i := 0; i+1 < len(tables.Content); i += 2 {
+		table := tables.Content[i].Value
+
+		var rows []map[string]any
+		if err := tables.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+
+		for _, row := range rows {
+			sql, args := fixtureInsert(table, row).Build()
+			if _, err := db.Exec(ctx, sql, args...); err != nil {
+				return fmt.Errorf("table %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+func fixtureInsert(table string, row map[string]any) *database.InsertBuilder {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = row[column]
+	}
+	return database.Insert(table, columns...).Values(values...)
+}
+
+// fixtureSeeder loads fixture files as a regular seeder
+type fixtureSeeder struct {
+	name      string
+	fsys      fs.FS
+	files     []string
+	dependsOn []string
+}
+
+// Fixtures creates a seeder that loads the given fixture files
+func Fixtures(name string, fsys fs.FS, files ...string) DependentSeeder {
+	return &fixtureSeeder{name: name, fsys: fsys, files: files}
+}
+
+// FixturesAfter creates a fixture seeder that runs after the named seeders
+func FixturesAfter(name string, dependsOn []string, fsys fs.FS, files ...string) DependentSeeder {
+	return &fixtureSeeder{name: name, fsys: fsys, files: files, dependsOn: dependsOn}
+}
+
+func (s *fixtureSeeder) Name() string {
+	return s.name
+}
+
+func (s *fixtureSeeder) DependsOn() []string {
+	return s.dependsOn
+}
+
+func (s *fixtureSeeder) Seed(ctx context.Context, db database.Querier) error {
+	return LoadFixtures(ctx, db, s.fsys, s.files...)
+}
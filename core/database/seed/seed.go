@@ -0,0 +1,155 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"go.uber.org/zap"
+)
+
+// Seeder inserts reference or sample data
+type Seeder interface {
+	Name() string
+	Seed(ctx context.Context, db database.Querier) error
+}
+
+// DependentSeeder is a Seeder that must run after the named seeders
+type DependentSeeder interface {
+	Seeder
+	DependsOn() []string
+}
+
+// Registry collects the seeders contributed by modules
+type Registry struct {
+	seeders map[string]Seeder
+	mu      sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		seeders: make(map[string]Seeder),
+	}
+}
+
+// Register adds seeders to the registry held by the container, creating it on first use.
+// Seeders are queued for autowiring so they can `inject` the services they need.
+// Call it from a module's Register method.
+func Register(container *core.Container, seeders ...Seeder) *Registry {
+	var registry *Registry
+	if err := container.Resolve(&registry); err != nil {
+		registry = NewRegistry()
+		container.Register(registry)
+	}
+
+	registry.Add(seeders...)
+	for _, seeder := range seeders {
+		container.AddForAutowiring(seeder)
+	}
+	return registry
+}
+
+// Add registers seeders, replacing any seeder with the same name
+func (r *Registry) Add(seeders ...Seeder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, seeder := range seeders {
+		r.seeders[seeder.Name()] = seeder
+	}
+}
+
+// Names returns the registered seeder names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.seeders))
+	for name := range r.seeders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Plan returns the named seeders (all when none are given) and their dependencies,
+// ordered so every seeder runs after the seeders it depends on
+func (r *Registry) Plan(names ...string) ([]Seeder, error) {
+	if len(names) == 0 {
+		names = r.Names()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var plan []Seeder
+	state := make(map[string]int) // 1 = visiting, 2 = planned
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("seeder dependency cycle: %v", append(path, name))
+		case 2:
+			return nil
+		}
+
+		seeder, ok := r.seeders[name]
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("seeder %s depends on unknown seeder %s", path[len(path)-1], name)
+			}
+			return fmt.Errorf("unknown seeder %s", name)
+		}
+
+		state[name] = 1
+		if dependent, ok := seeder.(DependentSeeder); ok {
+			for _, dependency := range dependent.DependsOn() {
+				if err := visit(dependency, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = 2
+		plan = append(plan, seeder)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Runner executes seeders inside a single transaction
+type Runner struct {
+	registry *Registry
+	tm       *database.TxManager
+}
+
+func NewRunner(registry *Registry, tm *database.TxManager) *Runner {
+	return &Runner{registry: registry, tm: tm}
+}
+
+// Run executes the named seeders (all when none are given) with their dependencies.
+// Either every seeder succeeds or nothing is written.
+func (r *Runner) Run(ctx context.Context, names ...string) error {
+	plan, err := r.registry.Plan(names...)
+	if err != nil {
+		return err
+	}
+
+	return r.tm.WithTx(ctx, func(ctx context.Context) error {
+		for _, seeder := range plan {
+			if err := seeder.Seed(ctx, r.tm.Querier(ctx)); err != nil {
+				return fmt.Errorf("seeder %s failed: %w", seeder.Name(), err)
+			}
+			logger.Log.Info("Seeder completed", zap.String("seeder", seeder.Name()))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Querier is the query surface shared by *pgxpool.Pool and pgx.Tx.
+// Repositories should depend on it so they work both inside and outside a transaction.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// DB is a Querier able to start transactions, such as *pgxpool.Pool
+type DB interface {
+	Querier
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions configures a top-level transaction. Nested calls run in a savepoint
+// of the outer transaction and ignore these options.
+type TxOptions struct {
+	IsoLevel pgx.TxIsoLevel
+	ReadOnly bool
+	// MaxRetries overrides TxManager.MaxRetries for serialization failures
+	MaxRetries int
+}
+
+const (
+	// DefaultTxRetries is how many times a transaction is retried after a serialization failure
+	DefaultTxRetries = 3
+
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+type txKey struct{}
+
+// TxFromContext returns the transaction bound to ctx by TxManager.WithTx
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// IsSerializationFailure reports whether err is a retryable transaction conflict
+// (SQLSTATE 40001 serialization_failure or 40P01 deadlock_detected)
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+	}
+	return false
+}
+
+// TxManager runs functions inside transactions carried by the context
+type TxManager struct {
+	db         DB
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+func NewTxManager(db DB) *TxManager {
+	return &TxManager{
+		db:         db,
+		MaxRetries: DefaultTxRetries,
+		Backoff:    20 * time.Millisecond,
+	}
+}
+
+// Querier returns the ambient transaction of ctx, or the pool when there is none
+func (m *TxManager) Querier(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return m.db
+}
+
+// WithTx runs fn in a read-write transaction using the server default isolation level
+func (m *TxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithTxOptions(ctx, TxOptions{}, fn)
+}
+
+// WithTxOptions runs fn in a transaction. The transaction is committed when fn returns
+// nil and rolled back otherwise. If ctx already carries a transaction, fn runs in a
+// savepoint instead. Top-level transactions are retried on serialization failures,
+// so fn must be safe to run more than once.
+func (m *TxManager) WithTxOptions(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("create savepoint: %w", err)
+		}
+		return runInTx(ctx, savepoint, fn)
+	}
+
+	retries := opts.MaxRetries
+	if retries <= 0 {
+		retries = m.MaxRetries
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, opts, fn)
+		if err == nil || !IsSerializationFailure(err) || attempt > retries {
+			return err
+		}
+
+		select {
+		case <-time.After(m.Backoff * time.Duration(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	txOptions := pgx.TxOptions{IsoLevel: opts.IsoLevel}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	tx, err := m.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	return runInTx(ctx, tx, fn)
+}
+
+// runInTx commits tx when fn succeeds and rolls it back on error or panic
+func runInTx(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) error {
+	// Rollback must still reach the server when ctx was cancelled
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback(cleanupCtx)
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(cleanupCtx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+var (
+	_ DB      = (*pgxpool.Pool)(nil)
+	_ Querier = (pgx.Tx)(nil)
+)
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// RequestIDKey is the locals key holding the ID assigned to each request
+const RequestIDKey = "requestid"
+
+// RequestID returns the ID assigned to the current request
+func RequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(RequestIDKey).(string); ok {
+		return id
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
+
+// UseExceptionFilters registers filters consulted, in order, by the global error handler
+func (a *App) UseExceptionFilters(filters ...ExceptionFilter) {
+	a.filters = append(a.filters, filters...)
+}
+
+// UseProblemDetails makes the global error handler emit RFC 9457 problem details
+// (application/problem+json) instead of the standard response envelope.
+// It is the same switch as APP_PROBLEM_DETAILS and also applies to Respond.
+func (a *App) UseProblemDetails(enabled bool) {
+	if a.Config == nil {
+		a.Config = &config.Config{}
+	}
+	a.Config.App.ProblemDetails = enabled
+}
+
+// handleError is the global error handler installed on every App
+func (a *App) handleError(c *fiber.Ctx, err error) error {
+	exception := a.toException(c, err)
+	a.logException(c, exception)
+	if a.problemDetails() {
+		return writeProblem(c, exception.Problem())
+	}
+	return c.Status(exception.Status).JSON(exception.Response())
+}
+
+// toException resolves an error to the exception that describes its response
+func (a *App) toException(c *fiber.Ctx, err error) *HttpException {
+	var httpException *HttpException
+	if errors.As(err, &httpException) {
+		return httpException
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return NewHttpException(problem.Status, problem.Detail).WithData(problem)
+	}
+
+	for _, filter := range a.filters {
+		if exception := filter.Catch(err, c); exception != nil {
+			return exception
+		}
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return NewHttpException(fiberErr.Code, fiberErr.Message)
+	}
+
+	// Unknown errors may carry internal details, only expose them outside production
+	message := "Internal Server Error"
+	if !a.isProduction() {
+		message = err.Error()
+	}
+	return InternalServerError(message).WithCause(err)
+}
+
+func (a *App) logException(c *fiber.Ctx, exception *HttpException) {
+	fields := []zap.Field{
+		zap.String("request_id", RequestID(c)),
+		zap.String("method", c.Method()),
+		zap.String("path", c.Path()),
+		zap.Int("status", exception.Status),
+		zap.String("message", exception.Message),
+	}
+	if exception.Cause != nil {
+		fields = append(fields, zap.Error(exception.Cause))
+	}
+
+	switch {
+	case exception.Status >= HttpStatusInternalServerError:
+		logger.Log.Error("Request failed", fields...)
+	case exception.Cause != nil:
+		logger.Log.Warn("Request rejected", fields...)
+	}
+}
+
+func (a *App) isProduction() bool {
+	return a.Config != nil && a.Config.App.IsProduction()
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HttpException is an error that carries the HTTP response it should produce
+type HttpException struct {
+	Status  int
+	Message string
+	Data    interface{}
+	Cause   error
+}
+
+// NewHttpException creates an exception for the given status code
+func NewHttpException(status int, message string) *HttpException {
+	return &HttpException{
+		Status:  status,
+		Message: message,
+	}
+}
+
+func (e *HttpException) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+func (e *HttpException) Unwrap() error {
+	return e.Cause
+}
+
+// WithData attaches a response payload to the exception
+func (e *HttpException) WithData(data interface{}) *HttpException {
+	e.Data = data
+	return e
+}
+
+// WithCause records the underlying error. The cause is logged, never sent to the client.
+func (e *HttpException) WithCause(err error) *HttpException {
+	e.Cause = err
+	return e
+}
+
+// Response converts the exception to the standard response envelope
+func (e *HttpException) Response() HttpResponseType[interface{}] {
+	return HttpErrorWithData(e.Message, e.Status, e.Data)
+}
+
+func BadRequest(message string) *HttpException {
+	return NewHttpException(HttpStatusBadRequest, message)
+}
+
+func Unauthorized(message string) *HttpException {
+	return NewHttpException(HttpStatusUnauthorized, message)
+}
+
+func Forbidden(message string) *HttpException {
+	return NewHttpException(HttpStatusForbidden, message)
+}
+
+func NotFound(message string) *HttpException {
+	return NewHttpException(HttpStatusNotFound, message)
+}
+
+func MethodNotAllowed(message string) *HttpException {
+	return NewHttpException(HttpStatusMethodNotAllowed, message)
+}
+
+func Conflict(message string) *HttpException {
+	return NewHttpException(HttpStatusConflict, message)
+}
+
+func Gone(message string) *HttpException {
+	return NewHttpException(HttpStatusGone, message)
+}
+
+func UnprocessableEntity(message string) *HttpException {
+	return NewHttpException(HttpStatusUnprocessableEntity, message)
+}
+
+func TooManyRequests(message string) *HttpException {
+	return NewHttpException(HttpStatusTooManyRequests, message)
+}
+
+func InternalServerError(message string) *HttpException {
+	return NewHttpException(HttpStatusInternalServerError, message)
+}
+
+func ServiceUnavailable(message string) *HttpException {
+	return NewHttpException(HttpStatusServiceUnavailable, message)
+}
+
+// ExceptionFilter maps an error to an HttpException.
+// Returning nil lets the next registered filter handle the error.
+type ExceptionFilter interface {
+	Catch(err error, c *fiber.Ctx) *HttpException
+}
+
+// ExceptionFilterFunc adapts a function to the ExceptionFilter interface
+type ExceptionFilterFunc func(err error, c *fiber.Ctx) *HttpException
+
+func (f ExceptionFilterFunc) Catch(err error, c *fiber.Ctx) *HttpException {
+	return f(err, c)
+}
+
+// CatchIs maps any error matching target (via errors.Is) to the given status and message
+func CatchIs(target error, status int, message string) ExceptionFilter {
+	return ExceptionFilterFunc(func(err error, c *fiber.Ctx) *HttpException {
+		if errors.Is(err, target) {
+			return NewHttpException(status, message).WithCause(err)
+		}
+		return nil
+	})
+}
+
+// CatchAs maps any error of type E (via errors.As) using the given function
+func CatchAs[E error](fn func(err E) *HttpException) ExceptionFilter {
+	return ExceptionFilterFunc(func(err error, c *fiber.Ctx) *HttpException {
+		var target E
+		if errors.As(err, &target) {
+			return fn(target)
+		}
+		return nil
+	})
+}
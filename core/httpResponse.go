@@ -3,10 +3,10 @@ package core
 import "log"
 
 type HttpResponseType[T interface{}] struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Status  bool   `json:"status"`
-	Data    T      `json:"data,omitempty"`
+	Code    int    `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+	Status  bool   `json:"status" xml:"status"`
+	Data    T      `json:"data,omitempty" xml:"data,omitempty"`
 }
 
 func HttpSuccess(message string, code int) HttpResponseType[interface{}] {
@@ -1,5 +1,9 @@
 package core
 
+// HttpStatusType is the original placeholder type, unchanged so existing
+// HttpStatusType{} values keep compiling.
+//
+// Deprecated: use Status, which carries the code and its helpers.
 type HttpStatusType struct{}
 
 const (
@@ -209,3 +213,76 @@ var HttpStatus = struct {
 	NotExtended:                   HttpStatusNotExtended,
 	NetworkAuthenticationRequired: HttpStatusNetworkAuthenticationRequired,
 }
+
+// statusTitles holds the reason phrase of every HttpStatus* code
+var statusTitles = map[int]string{
+	HttpStatusContinue:                      "Continue",
+	HttpStatusSwitchingProtocols:            "Switching Protocols",
+	HttpStatusProcessing:                    "Processing",
+	HttpStatusEarlyHints:                    "Early Hints",
+	HttpStatusOK:                            "OK",
+	HttpStatusCreated:                       "Created",
+	HttpStatusAccepted:                      "Accepted",
+	HttpStatusNonAuthoritativeInformation:   "Non-Authoritative Information",
+	HttpStatusNoContent:                     "No Content",
+	HttpStatusResetContent:                  "Reset Content",
+	HttpStatusPartialContent:                "Partial Content",
+	HttpStatusMultiStatus:                   "Multi-Status",
+	HttpStatusAlreadyReported:               "Already Reported",
+	HttpStatusIMUsed:                        "IM Used",
+	HttpStatusMultipleChoices:               "Multiple Choices",
+	HttpStatusMovedPermanently:              "Moved Permanently",
+	HttpStatusFound:                         "Found",
+	HttpStatusSeeOther:                      "See Other",
+	HttpStatusNotModified:                   "Not Modified",
+	HttpStatusUseProxy:                      "Use Proxy",
+	HttpStatusSwitchProxy:                   "Switch Proxy",
+	HttpStatusTemporaryRedirect:             "Temporary Redirect",
+	HttpStatusPermanentRedirect:             "Permanent Redirect",
+	HttpStatusBadRequest:                    "Bad Request",
+	HttpStatusUnauthorized:                  "Unauthorized",
+	HttpStatusPaymentRequired:               "Payment Required",
+	HttpStatusForbidden:                     "Forbidden",
+	HttpStatusNotFound:                      "Not Found",
+	HttpStatusMethodNotAllowed:              "Method Not Allowed",
+	HttpStatusNotAcceptable:                 "Not Acceptable",
+	HttpStatusProxyAuthRequired:             "Proxy Authentication Required",
+	HttpStatusRequestTimeout:                "Request Timeout",
+	HttpStatusConflict:                      "Conflict",
+	HttpStatusGone:                          "Gone",
+	HttpStatusLengthRequired:                "Length Required",
+	HttpStatusPreconditionFailed:            "Precondition Failed",
+	HttpStatusRequestEntityTooLarge:         "Request Entity Too Large",
+	HttpStatusRequestURITooLong:             "Request URI Too Long",
+	HttpStatusUnsupportedMediaType:          "Unsupported Media Type",
+	HttpStatusRequestedRangeNotSatisfiable:  "Requested Range Not Satisfiable",
+	HttpStatusExpectationFailed:             "Expectation Failed",
+	HttpStatusTeapot:                        "I'm a teapot",
+	HttpStatusMisdirectedRequest:            "Misdirected Request",
+	HttpStatusUnprocessableEntity:           "Unprocessable Entity",
+	HttpStatusLocked:                        "Locked",
+	HttpStatusFailedDependency:              "Failed Dependency",
+	HttpStatusTooEarly:                      "Too Early",
+	HttpStatusUpgradeRequired:               "Upgrade Required",
+	HttpStatusPreconditionRequired:          "Precondition Required",
+	HttpStatusTooManyRequests:               "Too Many Requests",
+	HttpStatusRequestHeaderFieldsTooLarge:   "Request Header Fields Too Large",
+	HttpStatusUnavailableForLegalReasons:    "Unavailable For Legal Reasons",
+	HttpStatusInternalServerError:           "Internal Server Error",
+	HttpStatusNotImplemented:                "Not Implemented",
+	HttpStatusBadGateway:                    "Bad Gateway",
+	HttpStatusServiceUnavailable:            "Service Unavailable",
+	HttpStatusGatewayTimeout:                "Gateway Timeout",
+	HttpStatusHTTPVersionNotSupported:       "HTTP Version Not Supported",
+	HttpStatusVariantAlsoNegotiates:         "Variant Also Negotiates",
+	HttpStatusInsufficientStorage:           "Insufficient Storage",
+	HttpStatusLoopDetected:                  "Loop Detected",
+	HttpStatusNotExtended:                   "Not Extended",
+	HttpStatusNetworkAuthenticationRequired: "Network Authentication Required",
+}
+
+// StatusTitle returns the reason phrase of an HTTP status code, e.g. "Not Found" for 404.
+// Unknown codes return an empty string.
+func StatusTitle(code int) string {
+	return statusTitles[code]
+}
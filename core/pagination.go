@@ -0,0 +1,238 @@
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Paginated is a page of items along with offset or cursor metadata
+type Paginated[T any] struct {
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewPage builds offset pagination metadata from the requested page and the total count
+func NewPage[T any](items []T, params PageParams, total int64) Paginated[T] {
+	if items == nil {
+		items = []T{}
+	}
+	totalPages := 0
+	if params.Limit > 0 {
+		totalPages = int((total + int64(params.Limit) - 1) / int64(params.Limit))
+	}
+	return Paginated[T]{
+		Items:      items,
+		Page:       params.Page,
+		Limit:      params.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+		HasMore:    params.Page < totalPages,
+	}
+}
+
+// NewCursorPage builds keyset pagination metadata; an empty nextCursor means the last page
+func NewCursorPage[T any](items []T, limit int, nextCursor string) Paginated[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Paginated[T]{
+		Items:      items,
+		Limit:      limit,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// ListItems exposes the items as list data, letting Respond render a page as CSV
+func (p Paginated[T]) ListItems() any {
+	return p.Items
+}
+
+// HttpPaginated wraps a page in the standard success envelope
+func HttpPaginated[T any](message string, page Paginated[T]) HttpResponseType[Paginated[T]] {
+	return HttpResponseType[Paginated[T]]{
+		Code:    HttpStatusOK,
+		Message: message,
+		Status:  true,
+		Data:    page,
+	}
+}
+
+// SortField is one entry of a sort parameter such as sort=-created_at,name
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// PageParams holds the pagination parameters of a request
+type PageParams struct {
+	Page   int
+	Limit  int
+	Sort   []SortField
+	Cursor string
+}
+
+// Offset returns the number of rows to skip for offset pagination
+func (p PageParams) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Limit
+}
+
+// PaginationConfig bounds what clients may request
+type PaginationConfig struct {
+	// DefaultLimit and MaxLimit fall back to DefaultPaginationConfig when 0
+	DefaultLimit int
+	MaxLimit     int
+	// SortableFields restricts sort fields; empty disallows sorting
+	SortableFields []string
+	DefaultSort    []SortField
+}
+
+// DefaultPaginationConfig is used by ParsePageParams when no config is given
+var DefaultPaginationConfig = PaginationConfig{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// ParsePageParams reads page, limit, sort and cursor from the query string.
+// Invalid values produce a BadRequest HttpException.
+func ParsePageParams(c *fiber.Ctx, configs ...PaginationConfig) (PageParams, error) {
+	cfg := DefaultPaginationConfig
+	if len(configs) > 0 {
+		cfg = configs[0]
+	}
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = DefaultPaginationConfig.DefaultLimit
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = max(DefaultPaginationConfig.MaxLimit, cfg.DefaultLimit)
+	}
+
+	params := PageParams{Page: 1, Limit: cfg.DefaultLimit, Sort: cfg.DefaultSort, Cursor: c.Query("cursor")}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return params, BadRequest("page must be a positive integer")
+		}
+		params.Page = page
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return params, BadRequest("limit must be a positive integer")
+		}
+		if limit > cfg.MaxLimit {
+			return params, BadRequest(fmt.Sprintf("limit must not exceed %d", cfg.MaxLimit))
+		}
+		params.Limit = limit
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		sort, err := parseSort(raw, cfg.SortableFields)
+		if err != nil {
+			return params, err
+		}
+		params.Sort = sort
+	}
+
+	return params, nil
+}
+
+func parseSort(raw string, allowed []string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			field.Field = part[1:]
+		}
+
+		permitted := false
+		for _, name := range allowed {
+			if name == field.Field {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return nil, BadRequest(fmt.Sprintf("cannot sort by %q", field.Field))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ErrInvalidCursor is returned for cursors that are malformed or were not signed by this server
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorCodec encodes keyset positions as HMAC-signed cursors. Cursors are
+// tamper-evident but not encrypted: clients can decode and read the position, so
+// keep secrets out of the cursor fields
+type CursorCodec struct {
+	secret []byte
+}
+
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{secret: secret}
+}
+
+// Encode signs the position values (typically the sort columns of the last item)
+func (cc *CursorCodec) Encode(values map[string]any) (string, error) {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + cc.sign(encoded), nil
+}
+
+// Decode verifies and decodes a cursor. Numbers are returned as json.Number to keep int64 precision.
+func (cc *CursorCodec) Decode(cursor string) (map[string]any, error) {
+	encoded, signature, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(cc.sign(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+func (cc *CursorCodec) sign(encoded string) string {
+	mac := hmac.New(sha256.New, cc.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
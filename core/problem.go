@@ -0,0 +1,179 @@
+package core
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ProblemMediaType is the content type of RFC 9457 problem details
+const ProblemMediaType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object. Extensions are serialized as
+// top-level members next to the standard ones.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// NewProblem creates a problem with the default title of status, e.g. "Not Found" for 404
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  StatusTitle(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ValidationProblem describes failed validation as a 422 problem listing the errors
+func ValidationProblem(errs []*ValidationError) *Problem {
+	return NewProblem(HttpStatusUnprocessableEntity, "Validation failed").With("errors", errs)
+}
+
+// ProblemFromException converts an exception to a problem. Validation errors become
+// the "errors" member and any other payload the "data" member. A problem carried by
+// the exception is copied, so it can safely be a shared package-level value.
+func ProblemFromException(e *HttpException) *Problem {
+	if problem, ok := e.Data.(*Problem); ok {
+		return problem.Clone()
+	}
+	return problemWithData(NewProblem(e.Status, e.Message), e.Data)
+}
+
+func problemWithData(problem *Problem, data any) *Problem {
+	switch data.(type) {
+	case nil:
+		return problem
+	case []*ValidationError, map[string][]string:
+		return problem.With("errors", data)
+	}
+	return problem.With("data", data)
+}
+
+// Problem converts the exception to RFC 9457 problem details
+func (e *HttpException) Problem() *Problem {
+	return ProblemFromException(e)
+}
+
+// Clone returns a copy of the problem with its own extensions map
+func (p *Problem) Clone() *Problem {
+	clone := *p
+	if p.Extensions != nil {
+		clone.Extensions = make(map[string]any, len(p.Extensions))
+		for key, value := range p.Extensions {
+			clone.Extensions[key] = value
+		}
+	}
+	return &clone
+}
+
+// With sets an extension member
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]any{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// WithType sets the URI identifying the problem type
+func (p *Problem) WithType(uri string) *Problem {
+	p.Type = uri
+	return p
+}
+
+// WithInstance sets the URI identifying this occurrence of the problem
+func (p *Problem) WithInstance(uri string) *Problem {
+	p.Instance = uri
+	return p
+}
+
+// Problem can be returned from handlers; the global error handler renders it as is
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// Members returns the problem as a flat map of standard and extension members
+func (p *Problem) Members() map[string]any {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	problemType := p.Type
+	if problemType == "" {
+		problemType = "about:blank"
+	}
+	members["type"] = problemType
+	members["status"] = p.Status
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return members
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Members())
+}
+
+func (p *Problem) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	return encoder.Encode(p.Members())
+}
+
+// MarshalXML writes the problem+xml format of RFC 9457 appendix B
+func (p *Problem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	members := p.Members()
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tree, err := jsonTree(members[key])
+		if err != nil {
+			return err
+		}
+		if err := encodeXMLElement(e, key, tree); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// writeProblem renders a problem as application/problem+json, defaulting the
+// instance to the request path on a copy, leaving the caller's problem untouched
+func writeProblem(c *fiber.Ctx, problem *Problem) error {
+	if problem.Instance == "" {
+		problem = problem.Clone()
+		problem.Instance = c.OriginalURL()
+	}
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ProblemMediaType)
+	return c.Status(problem.Status).Send(body)
+}
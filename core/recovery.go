@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// ErrorReporter forwards recovered panics to an external error tracker (Sentry, Rollbar, ...)
+type ErrorReporter interface {
+	ReportPanic(c *fiber.Ctx, recovered interface{}, stack []byte)
+}
+
+// SensitiveHeaders are replaced with a placeholder before request headers are logged
+var SensitiveHeaders = []string{
+	fiber.HeaderAuthorization,
+	fiber.HeaderProxyAuthorization,
+	fiber.HeaderCookie,
+	fiber.HeaderSetCookie,
+	"X-Api-Key",
+	"X-Csrf-Token",
+}
+
+const redactedValue = "[REDACTED]"
+
+// SetErrorReporter registers the tracker notified whenever a handler panics
+func (a *App) SetErrorReporter(reporter ErrorReporter) {
+	a.reporter = reporter
+}
+
+// recoverPanics turns handler panics into a logged 500 response
+func (a *App) recoverPanics(c *fiber.Ctx) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		stack := debug.Stack()
+		logger.Log.Error("Panic recovered",
+			zap.String("request_id", RequestID(c)),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.String("panic", fmt.Sprintf("%v", recovered)),
+			zap.Any("headers", redactHeaders(c.GetReqHeaders())),
+			zap.ByteString("stack", stack),
+		)
+
+		if a.reporter != nil {
+			a.reportPanic(c, recovered, stack)
+		}
+
+		if a.problemDetails() {
+			err = writeProblem(c, NewProblem(HttpStatusInternalServerError, ""))
+			return
+		}
+		err = c.Status(HttpStatusInternalServerError).
+			JSON(HttpError("Internal Server Error", HttpStatusInternalServerError))
+	}()
+
+	return c.Next()
+}
+
+// reportPanic shields the request from a misbehaving reporter
+func (a *App) reportPanic(c *fiber.Ctx, recovered interface{}, stack []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Log.Error("Error reporter panicked", zap.Any("reason", r))
+		}
+	}()
+	a.reporter.ReportPanic(c, recovered, stack)
+}
+
+func redactHeaders(headers map[string][]string) map[string][]string {
+	for name := range headers {
+		for _, sensitive := range SensitiveHeaders {
+			if strings.EqualFold(name, sensitive) {
+				headers[name] = []string{redactedValue}
+				break
+			}
+		}
+	}
+	return headers
+}
@@ -0,0 +1,389 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder writes response bodies of one media type
+type Encoder struct {
+	MediaType string
+	// ListOnly encoders are only offered for list data and receive the bare items
+	// instead of the envelope (e.g. CSV)
+	ListOnly bool
+	Encode   func(w io.Writer, v any) error
+}
+
+var (
+	encoders = []Encoder{
+		{MediaType: fiber.MIMEApplicationJSON, Encode: encodeJSON},
+		{MediaType: fiber.MIMEApplicationXML, Encode: encodeXML},
+		{MediaType: fiber.MIMETextXML, Encode: encodeXML},
+		{MediaType: "application/msgpack", Encode: encodeMsgpack},
+		{MediaType: "application/x-msgpack", Encode: encodeMsgpack},
+		{MediaType: "text/csv", ListOnly: true, Encode: encodeCSV},
+	}
+	encodersMu sync.RWMutex
+)
+
+// RegisterEncoder adds a response encoder, replacing any encoder of the same media type.
+// Encoders are offered in registration order when the Accept header has no preference.
+func RegisterEncoder(encoder Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	for i := range encoders {
+		if encoders[i].MediaType == encoder.MediaType {
+			encoders[i] = encoder
+			return
+		}
+	}
+	encoders = append(encoders, encoder)
+}
+
+// RemoveEncoder stops offering a media type, e.g. to disable XML responses
+func RemoveEncoder(mediaType string) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	for i := range encoders {
+		if encoders[i].MediaType == mediaType {
+			encoders = append(encoders[:i], encoders[i+1:]...)
+			return
+		}
+	}
+}
+
+// Respond writes resp with its Code as HTTP status, encoded in the format preferred
+// by the Accept header. Requests accepting none of the registered formats get a 406.
+// Error responses use problem details when the App has ProblemDetails enabled.
+// Bodies the negotiated format cannot represent (e.g. map keys that are not valid
+// XML names) are sent as JSON.
+func Respond[T any](c *fiber.Ctx, resp HttpResponseType[T]) error {
+	status := resp.Code
+	if status == 0 {
+		status = HttpStatusOK
+	}
+
+	items, isList := listItems(resp.Data)
+	encoder, ok := negotiate(c, isList)
+	if !ok {
+		return NewHttpException(HttpStatusNotAcceptable, "Not Acceptable")
+	}
+
+	// Encode before touching the response so a failure leaves it unwritten
+	var buf bytes.Buffer
+	body, contentType := responseBody(c, resp, status, encoder, items)
+	if err := encoder.Encode(&buf, body); err != nil {
+		if encoder.MediaType == fiber.MIMEApplicationJSON {
+			return err
+		}
+		encoder = Encoder{MediaType: fiber.MIMEApplicationJSON, Encode: encodeJSON}
+		body, contentType = responseBody(c, resp, status, encoder, items)
+		buf.Reset()
+		if err := encoder.Encode(&buf, body); err != nil {
+			return err
+		}
+	}
+
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Vary(fiber.HeaderAccept)
+	return c.Send(buf.Bytes())
+}
+
+// responseBody shapes resp for encoder: bare items for list-only formats, problem
+// details for errors when the App uses them, the standard envelope otherwise
+func responseBody[T any](c *fiber.Ctx, resp HttpResponseType[T], status int, encoder Encoder, items any) (any, string) {
+	switch {
+	case encoder.ListOnly:
+		return items, encoder.MediaType
+	case status >= HttpStatusBadRequest && appOf(c).problemDetails():
+		problem := problemWithData(NewProblem(status, resp.Message), resp.Data).WithInstance(c.OriginalURL())
+		return problem, problemMediaType(encoder.MediaType)
+	}
+	return resp, encoder.MediaType
+}
+
+// negotiate picks the registered encoder best matching the Accept header
+func negotiate(c *fiber.Ctx, isList bool) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	offers := make([]string, 0, len(encoders))
+	for _, encoder := range encoders {
+		if !encoder.ListOnly || isList {
+			offers = append(offers, encoder.MediaType)
+		}
+	}
+
+	accepted := c.Accepts(offers...)
+	for _, encoder := range encoders {
+		if encoder.MediaType == accepted {
+			return encoder, true
+		}
+	}
+	return Encoder{}, false
+}
+
+// listItems returns the items of list data: slices, arrays and paginated results
+func listItems(data any) (any, bool) {
+	if list, ok := data.(interface{ ListItems() any }); ok {
+		return list.ListItems(), true
+	}
+
+	value := reflect.ValueOf(data)
+	switch value.Kind() {
+	case reflect.Slice:
+		return data, value.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return data, true
+	}
+	return nil, false
+}
+
+// problemMediaType maps application/json to application/problem+json and XML to application/problem+xml
+func problemMediaType(mediaType string) string {
+	switch mediaType {
+	case fiber.MIMEApplicationJSON:
+		return ProblemMediaType
+	case fiber.MIMEApplicationXML, fiber.MIMETextXML:
+		return "application/problem+xml"
+	}
+	return mediaType
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if _, named := v.(xml.Marshaler); named {
+		return encoder.Encode(v)
+	}
+	tree, err := jsonTree(v)
+	if err != nil {
+		return err
+	}
+	if err := encodeXMLElement(encoder, "response", tree); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// jsonTree converts v to maps, slices and scalars through its JSON form, so XML
+// elements are named and shaped like the JSON body
+func jsonTree(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree any
+	return tree, decoder.Decode(&tree)
+}
+
+// encodeXMLElement writes a jsonTree value as element name. Object members become
+// child elements in key order and list items become <item> elements.
+func encodeXMLElement(e *xml.Encoder, name string, value any) error {
+	if !validXMLName(name) {
+		return fmt.Errorf("xml: invalid element name %q", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := encodeXMLElement(e, key, value[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if err := encodeXMLElement(e, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := e.EncodeToken(xml.CharData(fmt.Sprint(value))); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// validXMLName accepts the unprefixed names usable as element names
+func validXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
+
+// encodeCSV writes a slice of structs, maps or scalars as CSV with a header row.
+// Struct columns are named after their json tags.
+func encodeCSV(w io.Writer, v any) error {
+	items := reflect.ValueOf(v)
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return fmt.Errorf("csv: cannot encode %T", v)
+	}
+
+	writer := csv.NewWriter(w)
+	elemType := items.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	switch {
+	case elemType.Kind() == reflect.Struct:
+		fields := csvFields(elemType, nil)
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.name
+		}
+		writer.Write(header)
+		for i := 0; i < items.Len(); i++ {
+			item := reflect.Indirect(items.Index(i))
+			row := make([]string, len(fields))
+			if item.IsValid() {
+				for j, field := range fields {
+					if value, err := item.FieldByIndexErr(field.index); err == nil {
+						row[j] = csvValue(value)
+					}
+				}
+			}
+			writer.Write(row)
+		}
+	case elemType.Kind() == reflect.Map && elemType.Key().Kind() == reflect.String:
+		keys := map[string]bool{}
+		for i := 0; i < items.Len(); i++ {
+			if item := reflect.Indirect(items.Index(i)); item.IsValid() {
+				for _, key := range item.MapKeys() {
+					keys[key.String()] = true
+				}
+			}
+		}
+		header := make([]string, 0, len(keys))
+		for key := range keys {
+			header = append(header, key)
+		}
+		sort.Strings(header)
+		writer.Write(header)
+		for i := 0; i < items.Len(); i++ {
+			item := reflect.Indirect(items.Index(i))
+			row := make([]string, len(header))
+			for j, key := range header {
+				if !item.IsValid() {
+					break
+				}
+				if value := item.MapIndex(reflect.ValueOf(key).Convert(elemType.Key())); value.IsValid() {
+					row[j] = csvValue(value)
+				}
+			}
+			writer.Write(row)
+		}
+	default:
+		writer.Write([]string{"value"})
+		for i := 0; i < items.Len(); i++ {
+			writer.Write([]string{csvValue(items.Index(i))})
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields lists the exported fields of t by json name, flattening embedded structs
+func csvFields(t reflect.Type, parent []int) []csvField {
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, csvFields(field.Type, index)...)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{name: name, index: index})
+	}
+	return fields
+}
+
+func csvValue(value reflect.Value) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if !value.CanInterface() {
+		return ""
+	}
+
+	switch v := value.Interface().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		encoded, _ := json.Marshal(value.Interface())
+		return string(encoded)
+	}
+	return fmt.Sprint(value.Interface())
+}
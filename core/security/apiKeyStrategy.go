@@ -0,0 +1,115 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrAPIKeyNotFound is returned by APIKeyStore for unknown keys
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyStore looks up API keys by their hash; plain keys are never stored
+type APIKeyStore interface {
+	FindAPIKey(ctx context.Context, hash string) (*Principal, error)
+}
+
+// GenerateAPIKey creates a random key with an optional prefix (e.g. "sk_live_").
+// Show key to the client once and persist only hash.
+func GenerateAPIKey(prefix string) (key string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex SHA-256 of a key. Generated keys carry 256 bits of
+// entropy, so a fast hash is sufficient at rest.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryAPIKeyStore keeps API key hashes in memory, e.g. keys loaded from configuration
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]*Principal
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: map[string]*Principal{}}
+}
+
+// Add registers the hash of a key for principal
+func (s *MemoryAPIKeyStore) Add(hash string, principal *Principal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[hash] = principal
+}
+
+func (s *MemoryAPIKeyStore) Revoke(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, hash)
+}
+
+func (s *MemoryAPIKeyStore) FindAPIKey(_ context.Context, hash string) (*Principal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	principal, ok := s.keys[hash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	copied := *principal
+	return &copied, nil
+}
+
+// APIKeyStrategy authenticates keys sent in a header (X-API-Key by default) or,
+// when Query is set, in that query parameter
+type APIKeyStrategy struct {
+	Store  APIKeyStore
+	Header string
+	Query  string
+}
+
+func NewAPIKeyStrategy(store APIKeyStore) *APIKeyStrategy {
+	return &APIKeyStrategy{Store: store, Header: "X-API-Key"}
+}
+
+func (s *APIKeyStrategy) Name() string {
+	return "api_key"
+}
+
+func (s *APIKeyStrategy) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	var key string
+	if s.Header != "" {
+		key = c.Get(s.Header)
+	}
+	if key == "" && s.Query != "" {
+		key = c.Query(s.Query)
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, err := s.Store.FindAPIKey(c.UserContext(), HashAPIKey(key))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if principal.Type == "" {
+		principal.Type = "api_key"
+	}
+	return principal, nil
+}
@@ -0,0 +1,186 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+	// AuditDenied marks requests rejected by authentication or authorization
+	AuditDenied AuditOutcome = "denied"
+)
+
+// AuditRecord describes who did what, to what, and how it ended
+type AuditRecord struct {
+	ID        string         `json:"id"`
+	Time      time.Time      `json:"time"`
+	Actor     string         `json:"actor,omitempty"`
+	ActorType string         `json:"actor_type,omitempty"`
+	Action    string         `json:"action"`
+	Resource  string         `json:"resource,omitempty"`
+	Outcome   AuditOutcome   `json:"outcome"`
+	IP        string         `json:"ip,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// AuditSink stores audit records
+type AuditSink interface {
+	Write(ctx context.Context, record AuditRecord) error
+}
+
+// Auditor writes records to its sinks. A nil *Auditor records nothing, so
+// components can hold an optional one.
+type Auditor struct {
+	Sinks []AuditSink
+}
+
+func NewAuditor(sinks ...AuditSink) *Auditor {
+	return &Auditor{Sinks: sinks}
+}
+
+// Log completes the ID and time of record and writes it to every sink. Sink
+// failures are logged and do not fail the audited operation.
+func (a *Auditor) Log(ctx context.Context, record AuditRecord) {
+	if a == nil {
+		return
+	}
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	for _, sink := range a.Sinks {
+		if err := sink.Write(ctx, record); err != nil {
+			logger.Log.Error("Failed to write audit record",
+				zap.String("action", record.Action), zap.String("id", record.ID), zap.Error(err))
+		}
+	}
+}
+
+// Record logs action of the request, filling the actor, IP and request ID from c
+func (a *Auditor) Record(c *fiber.Ctx, action string, resource string, outcome AuditOutcome, metadata map[string]any) {
+	if a == nil {
+		return
+	}
+	record := AuditRecord{
+		Action:   action,
+		Resource: resource,
+		Outcome:  outcome,
+		IP:       c.IP(),
+		Metadata: metadata,
+	}
+	record.RequestID, _ = c.Locals(core.RequestIDKey).(string)
+	record.Actor, record.ActorType = auditActor(c)
+	a.Log(c.UserContext(), record)
+}
+
+const auditDetailsLocalsKey = "audit_details"
+
+type auditDetails struct {
+	resource string
+	metadata map[string]any
+}
+
+// AuditDetails sets the resource and metadata recorded by Audit for the request,
+// e.g. AuditDetails(c, "order:"+id, map[string]any{"amount": total})
+func AuditDetails(c *fiber.Ctx, resource string, metadata map[string]any) {
+	c.Locals(auditDetailsLocalsKey, &auditDetails{resource: resource, metadata: metadata})
+}
+
+// Audit decorates a route to record action once the handler ran. The outcome
+// follows the response: denied for 401/403, failure for other errors.
+//
+//	app.Delete("/users/:id", auth.Use(), auditor.Audit("user.delete"), handler)
+func (a *Auditor) Audit(action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		a.recordResponse(c, action, err)
+		return err
+	}
+}
+
+// AuditMutations records every non-GET/HEAD/OPTIONS request as "METHOD /route",
+// for use on a group or the whole app
+func (a *Auditor) AuditMutations() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		err := c.Next()
+		a.recordResponse(c, c.Method()+" "+c.Route().Path, err)
+		return err
+	}
+}
+
+func (a *Auditor) recordResponse(c *fiber.Ctx, action string, err error) {
+	if a == nil {
+		return
+	}
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = errorStatus(err)
+	}
+
+	outcome := AuditSuccess
+	switch {
+	case status == fiber.StatusUnauthorized || status == fiber.StatusForbidden:
+		outcome = AuditDenied
+	case status >= 400:
+		outcome = AuditFailure
+	}
+
+	resource := c.OriginalURL()
+	metadata := map[string]any{"status": status}
+	if details, ok := c.Locals(auditDetailsLocalsKey).(*auditDetails); ok {
+		if details.resource != "" {
+			resource = details.resource
+		}
+		for k, v := range details.metadata {
+			metadata[k] = v
+		}
+	}
+	a.Record(c, action, resource, outcome, metadata)
+}
+
+// errorStatus is the status the error handler answers err with, before exception filters
+func errorStatus(err error) int {
+	var exception *core.HttpException
+	if errors.As(err, &exception) {
+		return exception.Status
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	var problem *core.Problem
+	if errors.As(err, &problem) {
+		return problem.Status
+	}
+	return fiber.StatusInternalServerError
+}
+
+func auditActor(c *fiber.Ctx) (string, string) {
+	if principal := CurrentUser(c); principal != nil {
+		return principal.ID, principal.Type
+	}
+	if claims, ok := c.Locals(UserLocalsKey).(map[string]interface{}); ok {
+		if subject, ok := claims["sub"].(string); ok {
+			return subject, "user"
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,237 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// LoggerAuditSink writes records to the application logger
+type LoggerAuditSink struct{}
+
+func (LoggerAuditSink) Write(_ context.Context, record AuditRecord) error {
+	logger.Log.Info("audit",
+		zap.String("audit_id", record.ID),
+		zap.Time("time", record.Time),
+		zap.String("actor", record.Actor),
+		zap.String("actor_type", record.ActorType),
+		zap.String("action", record.Action),
+		zap.String("resource", record.Resource),
+		zap.String("outcome", string(record.Outcome)),
+		zap.String("ip", record.IP),
+		zap.String("request_id", record.RequestID),
+		zap.Any("metadata", record.Metadata),
+	)
+	return nil
+}
+
+// PostgresAuditSink inserts records into Table. Inside database.TxManager.WithTx the
+// record is written by the transaction, so it is only kept if the change is.
+type PostgresAuditSink struct {
+	DB    database.Querier
+	Table string
+}
+
+func NewPostgresAuditSink(db database.Querier) *PostgresAuditSink {
+	return &PostgresAuditSink{DB: db, Table: "audit_logs"}
+}
+
+// CreateTable creates the audit table when missing
+func (s *PostgresAuditSink) CreateTable(ctx context.Context) error {
+	_, err := s.DB.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+s.table()+` (
+		id UUID PRIMARY KEY,
+		time TIMESTAMPTZ NOT NULL,
+		actor TEXT,
+		actor_type TEXT,
+		action TEXT NOT NULL,
+		resource TEXT,
+		outcome TEXT NOT NULL,
+		ip TEXT,
+		request_id TEXT,
+		metadata JSONB
+	)`)
+	return err
+}
+
+func (s *PostgresAuditSink) Write(ctx context.Context, record AuditRecord) error {
+	var db database.Querier = s.DB
+	if tx, ok := database.TxFromContext(ctx); ok {
+		db = tx
+	}
+	metadata, err := json.Marshal(record.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, "INSERT INTO "+s.table()+
+		" (id, time, actor, actor_type, action, resource, outcome, ip, request_id, metadata)"+
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		record.ID, record.Time, record.Actor, record.ActorType, record.Action,
+		record.Resource, string(record.Outcome), record.IP, record.RequestID, metadata)
+	return err
+}
+
+func (s *PostgresAuditSink) table() string {
+	return pgx.Identifier(strings.Split(s.Table, ".")).Sanitize()
+}
+
+// chainedRecord is a line of a FileAuditSink: the record, the hash of the previous
+// line and the hash of both. The record is kept as raw JSON so verification hashes
+// the exact bytes that were written.
+type chainedRecord struct {
+	PrevHash string          `json:"prev_hash"`
+	Record   json.RawMessage `json:"record"`
+	Hash     string          `json:"hash"`
+}
+
+// FileAuditSink appends records as JSON lines to a file, each chained to the
+// previous one by an HMAC-SHA256 under Key. Without the key, editing, removing or
+// reordering lines breaks the chain, which VerifyAuditFile detects. Removing lines
+// from the end leaves a valid chain: publish Head to a separate system (e.g. on a
+// schedule) and compare it when verifying to detect truncation.
+type FileAuditSink struct {
+	mu       sync.Mutex
+	key      []byte
+	file     *os.File
+	lastHash string
+}
+
+// OpenFileAuditSink opens path for appending, continuing the chain of its last line.
+// A trailing partial line, left by a crash during a write, is discarded with a
+// warning; any other broken line is reported as an error.
+func OpenFileAuditSink(path string, key []byte) (*FileAuditSink, error) {
+	if len(key) == 0 {
+		return nil, errors.New("audit chain key is empty")
+	}
+	chain, err := verifyAuditChain(path, key, "")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if chain.partial {
+		logger.Log.Warn("Discarding partial audit line", zap.String("path", path), zap.Int64("offset", chain.size))
+		if err := file.Truncate(chain.size); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &FileAuditSink{key: key, file: file, lastHash: chain.head}, nil
+}
+
+func (s *FileAuditSink) Write(_ context.Context, record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	hash := chainHash(s.key, s.lastHash, raw)
+	data, err := json.Marshal(chainedRecord{PrevHash: s.lastHash, Record: raw, Hash: hash})
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.lastHash = hash
+	return nil
+}
+
+// Head returns the hash of the last record, to be anchored outside the file
+func (s *FileAuditSink) Head() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastHash
+}
+
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+// VerifyAuditFile checks the hash chain of a FileAuditSink file and returns the
+// number of valid records, with an error naming the first broken line. Pass a
+// previously anchored head to also detect records removed from the end; an empty
+// head skips that check.
+func VerifyAuditFile(path string, key []byte, head string) (int, error) {
+	chain, err := verifyAuditChain(path, key, head)
+	switch {
+	case err != nil:
+		return chain.count, err
+	case chain.partial:
+		return chain.count, fmt.Errorf("audit line %d: incomplete", chain.count+1)
+	case head != "" && !chain.anchored:
+		return chain.count, errors.New("audit file does not reach the anchored head")
+	}
+	return chain.count, nil
+}
+
+// auditChain summarises a FileAuditSink file
+type auditChain struct {
+	head  string
+	count int
+	// anchored reports whether a line hashed to the anchor passed to verifyAuditChain
+	anchored bool
+	// size is the length of the valid lines; partial reports bytes after them
+	// without a final newline
+	size    int64
+	partial bool
+}
+
+func verifyAuditChain(path string, key []byte, anchor string) (auditChain, error) {
+	var chain auditChain
+	file, err := os.Open(path)
+	if err != nil {
+		return chain, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			chain.partial = len(data) > 0
+			return chain, nil
+		}
+		if err != nil {
+			return chain, err
+		}
+
+		var line chainedRecord
+		if err := json.Unmarshal(data, &line); err != nil {
+			return chain, fmt.Errorf("audit line %d: %w", chain.count+1, err)
+		}
+		if line.PrevHash != chain.head || !hmac.Equal([]byte(line.Hash), []byte(chainHash(key, chain.head, line.Record))) {
+			return chain, fmt.Errorf("audit line %d: hash chain broken", chain.count+1)
+		}
+		chain.head = line.Hash
+		chain.anchored = chain.anchored || line.Hash == anchor
+		chain.count++
+		chain.size += int64(len(data))
+	}
+}
+
+// chainHash is the HMAC-SHA256 of the previous hash and the record
+func chainHash(key []byte, prevHash string, record []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write(record)
+	return hex.EncodeToString(h.Sum(nil))
+}
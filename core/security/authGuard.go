@@ -1,33 +1,22 @@
 package security
 
 import (
-	"strings"
-
 	"github.com/gofiber/fiber/v2"
 )
 
+// UserLocalsKey is the locals key holding the claims of the authenticated user
+const UserLocalsKey = "user"
+
+// AuthGuard accepts requests with a valid, non revoked "Authorization: Bearer <jwt>" header.
+// The claims are stored under UserLocalsKey and the Principal is available via CurrentUser.
 type AuthGuard struct {
 	JwtService *JwtService `inject:"type"`
+	// Auditor, when set, records rejected tokens
+	Auditor *Auditor
 }
 
 func (g *AuthGuard) CanActivate(c *fiber.Ctx) bool {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return false
-	}
-
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return false
-	}
-
-	token := parts[1]
-	claims, err := g.JwtService.Verify(token)
-	if err != nil {
-		return false
-	}
-
-	// Store user in locals
-	c.Locals("user", claims)
-	return true
+	authenticator := Authenticate(NewJwtStrategy(g.JwtService))
+	authenticator.Auditor = g.Auditor
+	return authenticator.CanActivate(c)
 }
@@ -0,0 +1,55 @@
+package security
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BasicVerifier checks a username and password, returning ErrInvalidCredentials when they do not match
+type BasicVerifier func(ctx context.Context, username string, password string) (*Principal, error)
+
+// BasicStrategy authenticates HTTP Basic credentials
+type BasicStrategy struct {
+	Realm  string
+	Verify BasicVerifier
+}
+
+func NewBasicStrategy(realm string, verify BasicVerifier) *BasicStrategy {
+	return &BasicStrategy{Realm: realm, Verify: verify}
+}
+
+func (s *BasicStrategy) Name() string {
+	return "basic"
+}
+
+func (s *BasicStrategy) Challenge() string {
+	return `Basic realm="` + strings.ReplaceAll(s.Realm, `"`, `'`) + `", charset="UTF-8"`
+}
+
+func (s *BasicStrategy) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	scheme, encoded, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		return nil, ErrNoCredentials
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	username, password, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, ErrInvalidCredentials
+	}
+
+	principal, err := s.Verify(c.UserContext(), username, password)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return principal, nil
+}
@@ -0,0 +1,49 @@
+package security
+
+import (
+	"crypto/x509"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientCertificate returns the verified client certificate presented over mutual TLS, if any
+func ClientCertificate(c *fiber.Ctx) *x509.Certificate {
+	state := c.Context().TLSConnectionState()
+	if state == nil {
+		return nil
+	}
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return state.VerifiedChains[0][0]
+	}
+	return nil
+}
+
+// ClientCertGuard only allows requests authenticated with a verified client certificate.
+// When AllowedCommonNames is set, the certificate subject must match one of them.
+type ClientCertGuard struct {
+	AllowedCommonNames []string
+}
+
+func (g *ClientCertGuard) CanActivate(c *fiber.Ctx) bool {
+	cert := ClientCertificate(c)
+	if cert == nil {
+		return false
+	}
+
+	if len(g.AllowedCommonNames) > 0 {
+		allowed := false
+		for _, name := range g.AllowedCommonNames {
+			if cert.Subject.CommonName == name {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	// Store client identity in locals
+	c.Locals("client_cert", cert)
+	return true
+}
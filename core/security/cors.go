@@ -0,0 +1,28 @@
+package security
+
+import (
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORS answers preflight requests and sets the Access-Control-* headers from the
+// CORS_* settings of config.CorsConfig
+type CORS struct {
+	Config config.CorsConfig
+}
+
+func NewCORS(cfg config.CorsConfig) *CORS {
+	return &CORS{Config: cfg}
+}
+
+func (m *CORS) Use() fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     m.Config.AllowOrigins,
+		AllowMethods:     m.Config.AllowMethods,
+		AllowHeaders:     m.Config.AllowHeaders,
+		ExposeHeaders:    m.Config.ExposeHeaders,
+		AllowCredentials: m.Config.AllowCredentials,
+		MaxAge:           m.Config.MaxAge,
+	})
+}
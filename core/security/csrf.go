@@ -0,0 +1,217 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CSRFLocalsKey holds the token of the request, to render in forms or a meta tag
+const CSRFLocalsKey = "csrf"
+
+type CSRFMode int
+
+const (
+	// CSRFDoubleSubmit sends the token as a cookie readable by scripts; unsafe requests
+	// must echo it in the header or form field. Tokens are signed with Secret over the
+	// session, so a cookie planted by a sibling subdomain is rejected.
+	CSRFDoubleSubmit CSRFMode = iota
+	// CSRFSynchronizer keeps the token server side in a cache.Store, per session
+	CSRFSynchronizer
+)
+
+// CSRF protects cookie authenticated requests against cross-site request forgery.
+// Safe methods (GET, HEAD, OPTIONS, TRACE) are let through and issue the token;
+// others must present it in HeaderName or FormField.
+type CSRF struct {
+	Mode       CSRFMode
+	CookieName string
+	HeaderName string
+	FormField  string
+	TTL        time.Duration
+	Secure     bool
+	SameSite   string
+	// Secret signs CSRFDoubleSubmit tokens and is required by that mode
+	Secret []byte
+	// Session identifies the session of the request, e.g. its session cookie.
+	// CSRFDoubleSubmit binds tokens to it when set. CSRFSynchronizer requires it
+	// along with Store, and lets requests without a session through as they are
+	// not cookie authenticated.
+	Store   cache.Store
+	Session func(c *fiber.Ctx) string
+	// Skip exempts requests, e.g. webhooks authenticated by signature
+	Skip func(c *fiber.Ctx) bool
+}
+
+func newCSRF(mode CSRFMode) *CSRF {
+	return &CSRF{
+		Mode:       mode,
+		CookieName: "csrf_token",
+		HeaderName: "X-CSRF-Token",
+		FormField:  "_csrf",
+		TTL:        12 * time.Hour,
+		Secure:     true,
+		SameSite:   fiber.CookieSameSiteLaxMode,
+	}
+}
+
+// NewDoubleSubmitCSRF protects with a token cookie echoed by the client, signed with
+// secret and bound to the session when session is not nil
+func NewDoubleSubmitCSRF(secret []byte, session func(c *fiber.Ctx) string) *CSRF {
+	m := newCSRF(CSRFDoubleSubmit)
+	m.Secret = secret
+	m.Session = session
+	return m
+}
+
+// NewSynchronizerCSRF protects with a per session token kept in store
+func NewSynchronizerCSRF(store cache.Store, session func(c *fiber.Ctx) string) *CSRF {
+	m := newCSRF(CSRFSynchronizer)
+	m.Store = store
+	m.Session = session
+	return m
+}
+
+var errInvalidCSRFToken = errors.New("invalid CSRF token")
+
+func (m *CSRF) Use() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if m.Skip != nil && m.Skip(c) {
+			return c.Next()
+		}
+
+		expected, err := m.token(c)
+		if err != nil {
+			return err
+		}
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+		default:
+			if m.Mode == CSRFSynchronizer && m.Session(c) == "" {
+				break
+			}
+			if !validCSRFToken(m.submitted(c), expected) {
+				return core.Forbidden(errInvalidCSRFToken.Error())
+			}
+		}
+
+		c.Locals(CSRFLocalsKey, expected)
+		return c.Next()
+	}
+}
+
+// token returns the current token, issuing one when missing
+func (m *CSRF) token(c *fiber.Ctx) (string, error) {
+	if m.Mode == CSRFSynchronizer {
+		return m.sessionToken(c)
+	}
+
+	if len(m.Secret) == 0 {
+		return "", errors.New("csrf: Secret is required for double-submit tokens")
+	}
+	// Tokens of another session, or not issued here, are replaced
+	if token := c.Cookies(m.CookieName); token != "" && m.validSignature(c, token) {
+		return token, nil
+	}
+	token, err := m.signedToken(c)
+	if err != nil {
+		return "", err
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     m.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(m.TTL),
+		Secure:   m.Secure,
+		HTTPOnly: false,
+		SameSite: m.SameSite,
+	})
+	return token, nil
+}
+
+// signedToken returns a random value with its signature: value.mac
+func (m *CSRF) signedToken(c *fiber.Ctx) (string, error) {
+	value, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	return value + "." + m.signature(c, value), nil
+}
+
+func (m *CSRF) validSignature(c *fiber.Ctx, token string) bool {
+	value, signature, ok := strings.Cut(token, ".")
+	return ok && hmac.Equal([]byte(signature), []byte(m.signature(c, value)))
+}
+
+// signature is the HMAC of value and the request's session
+func (m *CSRF) signature(c *fiber.Ctx, value string) string {
+	var session string
+	if m.Session != nil {
+		session = m.Session(c)
+	}
+	mac := hmac.New(sha256.New, m.Secret)
+	mac.Write([]byte(session))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (m *CSRF) sessionToken(c *fiber.Ctx) (string, error) {
+	session := m.Session(c)
+	if session == "" {
+		return "", nil
+	}
+
+	key := "csrf:" + session
+	var token string
+	err := m.Store.Get(c.UserContext(), key, &token)
+	if err == nil && token != "" {
+		return token, nil
+	}
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		return "", err
+	}
+
+	if token, err = newCSRFToken(); err != nil {
+		return "", err
+	}
+	return token, m.Store.Set(c.UserContext(), key, token, m.TTL)
+}
+
+func (m *CSRF) submitted(c *fiber.Ctx) string {
+	if token := c.Get(m.HeaderName); token != "" {
+		return token
+	}
+	return c.FormValue(m.FormField)
+}
+
+// CSRFToken returns the token to submit with unsafe requests
+func CSRFToken(c *fiber.Ctx) string {
+	token, _ := c.Locals(CSRFLocalsKey).(string)
+	return token
+}
+
+func validCSRFToken(submitted string, expected string) bool {
+	if submitted == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(submitted), []byte(expected)) == 1
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package security
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// ColumnEncrypter encrypts EncryptedString columns; set it at startup, e.g. from
+// LoadEncrypterFromEnv
+var ColumnEncrypter *Encrypter
+
+var errNoColumnEncrypter = errors.New("security.ColumnEncrypter is not set")
+
+// EncryptedString is a string stored encrypted in a text column. It implements
+// sql.Scanner and driver.Valuer, so pgx and the repository decrypt it on scan and
+// encrypt it on write.
+type EncryptedString string
+
+func (s EncryptedString) Value() (driver.Value, error) {
+	if ColumnEncrypter == nil {
+		return nil, errNoColumnEncrypter
+	}
+	return ColumnEncrypter.EncryptString(string(s))
+}
+
+func (s *EncryptedString) Scan(src any) error {
+	if ColumnEncrypter == nil {
+		return errNoColumnEncrypter
+	}
+
+	var ciphertext string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EncryptedString", src)
+	}
+
+	plaintext, err := ColumnEncrypter.DecryptString(ciphertext)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// String prevents the plaintext from leaking through %v formatting in logs
+func (s EncryptedString) String() string {
+	return "[encrypted]"
+}
@@ -0,0 +1,208 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrUnknownKeyID      = errors.New("unknown encryption key id")
+)
+
+// Encrypter provides AES-GCM authenticated encryption. Ciphertexts embed the id of
+// the key that sealed them, so keys can be rotated while older data stays readable:
+//
+//	len(kid) | kid | nonce | sealed
+type Encrypter struct {
+	mu     sync.RWMutex
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// NewEncrypter encrypts with key (16, 24 or 32 bytes for AES-128/192/256) under keyID
+func NewEncrypter(keyID string, key []byte) (*Encrypter, error) {
+	e := &Encrypter{keys: map[string]cipher.AEAD{}}
+	if err := e.Rotate(keyID, key); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// LoadEncrypterFromEnv reads ENCRYPTION_KEYS, a comma separated list of
+// kid:base64key; the first key encrypts, the others only decrypt
+func LoadEncrypterFromEnv() (*Encrypter, error) {
+	var e *Encrypter
+	for _, entry := range strings.Split(viper.GetString("ENCRYPTION_KEYS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		kid, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS: expected kid:base64key, got %q", kid)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS: key %q: %w", kid, err)
+		}
+		if e == nil {
+			e, err = NewEncrypter(kid, key)
+		} else {
+			err = e.AddKey(kid, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// GenerateEncryptionKey returns a random AES-256 key
+func GenerateEncryptionKey() ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+// AddKey makes key available for decryption
+func (e *Encrypter) AddKey(keyID string, key []byte) error {
+	if keyID == "" || len(keyID) > 255 {
+		return errors.New("encryption key id must be 1 to 255 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys[keyID] = aead
+	return nil
+}
+
+// Rotate adds key and encrypts with it from now on
+func (e *Encrypter) Rotate(keyID string, key []byte) error {
+	if err := e.AddKey(keyID, key); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active = keyID
+	return nil
+}
+
+// RemoveKey drops a retired key; data it sealed can no longer be decrypted
+func (e *Encrypter) RemoveKey(keyID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if keyID != e.active {
+		delete(e.keys, keyID)
+	}
+}
+
+// ActiveKeyID returns the id of the key used by Encrypt
+func (e *Encrypter) ActiveKeyID() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.active
+}
+
+// Encrypt seals plaintext. associatedData, when given, is authenticated but not
+// encrypted and must be passed again to Decrypt, e.g. the row id to bind a value to.
+func (e *Encrypter) Encrypt(plaintext []byte, associatedData ...[]byte) ([]byte, error) {
+	e.mu.RLock()
+	kid := e.active
+	aead := e.keys[kid]
+	e.mu.RUnlock()
+
+	out := make([]byte, 0, 1+len(kid)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out = append(out, byte(len(kid)))
+	out = append(out, kid...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, additionalData(kid, associatedData)), nil
+}
+
+func (e *Encrypter) Decrypt(ciphertext []byte, associatedData ...[]byte) ([]byte, error) {
+	kid, rest, err := KeyIDOf(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	aead, ok := e.keys[kid]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData(kid, associatedData))
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// EncryptString seals plaintext into URL safe base64
+func (e *Encrypter) EncryptString(plaintext string, associatedData ...[]byte) (string, error) {
+	ciphertext, err := e.Encrypt([]byte(plaintext), associatedData...)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+func (e *Encrypter) DecryptString(ciphertext string, associatedData ...[]byte) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	plaintext, err := e.Decrypt(raw, associatedData...)
+	return string(plaintext), err
+}
+
+// NeedsReencrypt reports whether ciphertext was sealed with a key other than the active one
+func (e *Encrypter) NeedsReencrypt(ciphertext []byte) bool {
+	kid, _, err := KeyIDOf(ciphertext)
+	return err != nil || kid != e.ActiveKeyID()
+}
+
+// KeyIDOf splits the key id from a ciphertext produced by Encrypt
+func KeyIDOf(ciphertext []byte) (string, []byte, error) {
+	if len(ciphertext) == 0 || int(ciphertext[0]) == 0 || len(ciphertext) < 1+int(ciphertext[0]) {
+		return "", nil, ErrInvalidCiphertext
+	}
+	n := int(ciphertext[0])
+	return string(ciphertext[1 : 1+n]), ciphertext[1+n:], nil
+}
+
+// additionalData binds the key id to the ciphertext so it cannot be swapped. Parts
+// are length prefixed so that ("ab", "c") and ("a", "bc") differ.
+func additionalData(kid string, associatedData [][]byte) []byte {
+	data := []byte(kid)
+	for _, part := range associatedData {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(part)))
+		data = append(data, part...)
+	}
+	return data
+}
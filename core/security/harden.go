@@ -0,0 +1,38 @@
+package security
+
+import (
+	"github.com/Alexigbokwe/goNextCore/core"
+)
+
+// HardenOptions selects the middleware installed by Harden
+type HardenOptions struct {
+	// Headers defaults to NewSecurityHeaders()
+	Headers *SecurityHeaders
+	// CORS defaults to the app's config.CorsConfig, when CORS_ALLOW_ORIGINS is set
+	CORS *CORS
+	// CSRF is needed when authenticating with cookies; nil disables it
+	CSRF *CSRF
+}
+
+// Harden installs security headers, CORS and CSRF protection on every route.
+// Call it before registering routes.
+func Harden(app *core.App, opts HardenOptions) {
+	headers := opts.Headers
+	if headers == nil {
+		headers = NewSecurityHeaders()
+	}
+	middlewares := []core.Middleware{headers}
+
+	if opts.CORS != nil {
+		middlewares = append(middlewares, opts.CORS)
+	} else if app.Config != nil && app.Config.Cors.AllowOrigins != "" {
+		middlewares = append(middlewares, NewCORS(app.Config.Cors))
+	}
+	if opts.CSRF != nil {
+		middlewares = append(middlewares, opts.CSRF)
+	}
+
+	for _, handler := range core.Combine(middlewares...) {
+		app.Use(handler)
+	}
+}
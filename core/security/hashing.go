@@ -1,6 +1,17 @@
 package security
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -8,23 +19,261 @@ import (
 type HashService interface {
 	Hash(password string) (string, error)
 	Compare(hashedPassword string, password string) bool
+	// NeedsRehash reports whether a hash should be replaced on the next successful
+	// login, e.g. after raising the cost or switching algorithms
+	NeedsRehash(hashedPassword string) bool
 }
 
-// BcryptService implementation
-type BcryptService struct {
-	Cost int
+// HashAlgorithm is a HashService recognising its own hashes
+type HashAlgorithm interface {
+	HashService
+	Matches(hashedPassword string) bool
 }
 
+// NewHashService hashes new passwords with the algorithm of HASH_ALGORITHM
+// (argon2id by default, or bcrypt) and verifies both argon2id and bcrypt hashes.
+// Costs come from BCRYPT_COST and ARGON2_MEMORY / ARGON2_ITERATIONS / ARGON2_PARALLELISM,
+// and HASH_PEPPER keys the bcrypt pre-hash.
 func NewHashService() HashService {
-	return &BcryptService{Cost: bcrypt.DefaultCost}
+	argon := NewArgon2idService()
+	if memory := viper.GetUint32("ARGON2_MEMORY"); memory > 0 {
+		argon.Memory = memory
+	}
+	if iterations := viper.GetUint32("ARGON2_ITERATIONS"); iterations > 0 {
+		argon.Iterations = iterations
+	}
+	if parallelism := viper.GetUint("ARGON2_PARALLELISM"); parallelism > 0 {
+		argon.Parallelism = uint8(parallelism)
+	}
+
+	bcryptService := NewBcryptService()
+	if cost := viper.GetInt("BCRYPT_COST"); cost > 0 {
+		bcryptService.Cost = cost
+	}
+	if pepper := viper.GetString("HASH_PEPPER"); pepper != "" {
+		bcryptService.Pepper = []byte(pepper)
+	}
+
+	if viper.GetString("HASH_ALGORITHM") == "bcrypt" {
+		return NewMultiHashService(bcryptService, argon)
+	}
+	return NewMultiHashService(argon, bcryptService)
+}
+
+// MultiHashService hashes with Preferred and verifies hashes of any of its algorithms,
+// recognised by prefix. Hashes of other algorithms need rehashing.
+type MultiHashService struct {
+	Preferred  HashAlgorithm
+	Algorithms []HashAlgorithm
+}
+
+func NewMultiHashService(preferred HashAlgorithm, others ...HashAlgorithm) *MultiHashService {
+	return &MultiHashService{
+		Preferred:  preferred,
+		Algorithms: append([]HashAlgorithm{preferred}, others...),
+	}
+}
+
+func (s *MultiHashService) Hash(password string) (string, error) {
+	return s.Preferred.Hash(password)
+}
+
+func (s *MultiHashService) Compare(hashedPassword string, password string) bool {
+	for _, algorithm := range s.Algorithms {
+		if algorithm.Matches(hashedPassword) {
+			return algorithm.Compare(hashedPassword, password)
+		}
+	}
+	return false
+}
+
+func (s *MultiHashService) NeedsRehash(hashedPassword string) bool {
+	if !s.Preferred.Matches(hashedPassword) {
+		return true
+	}
+	return s.Preferred.NeedsRehash(hashedPassword)
+}
+
+// BcryptService implementation. With PreHash, passwords are reduced to a base64
+// HMAC-SHA256 digest before bcrypt so bytes beyond bcrypt's 72 byte limit still
+// count; such hashes are stored with the "$bcrypt-hmac-sha256$" prefix. The HMAC is
+// keyed with Pepper (a fixed key when empty) so leaked plain SHA-256 digests cannot
+// be tried against the bcrypt hashes. Changing Pepper invalidates existing hashes.
+type BcryptService struct {
+	Cost    int
+	PreHash bool
+	Pepper  []byte
+}
+
+const bcryptHmacPrefix = "$bcrypt-hmac-sha256$"
+
+// defaultPepper separates the pre-hash from plain SHA-256 when no pepper is configured
+var defaultPepper = []byte("goNextCore bcrypt pre-hash")
+
+func NewBcryptService() *BcryptService {
+	return &BcryptService{Cost: bcrypt.DefaultCost, PreHash: true}
 }
 
 func (s *BcryptService) Hash(password string) (string, error) {
+	if s.PreHash {
+		bytes, err := bcrypt.GenerateFromPassword(s.preHash(password), s.Cost)
+		return bcryptHmacPrefix + string(bytes), err
+	}
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.Cost)
 	return string(bytes), err
 }
 
 func (s *BcryptService) Compare(hashedPassword string, password string) bool {
+	if inner, ok := strings.CutPrefix(hashedPassword, bcryptHmacPrefix); ok {
+		return bcrypt.CompareHashAndPassword([]byte(inner), s.preHash(password)) == nil
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+func (s *BcryptService) NeedsRehash(hashedPassword string) bool {
+	inner, preHashed := strings.CutPrefix(hashedPassword, bcryptHmacPrefix)
+	if preHashed != s.PreHash {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(inner))
+	return err != nil || cost != s.Cost
+}
+
+func (s *BcryptService) Matches(hashedPassword string) bool {
+	for _, prefix := range []string{bcryptHmacPrefix, "$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hashedPassword, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BcryptService) preHash(password string) []byte {
+	key := s.Pepper
+	if len(key) == 0 {
+		key = defaultPepper
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// Argon2idService hashes passwords with Argon2id, encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+type Argon2idService struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idService uses the OWASP recommended minimum parameters
+func NewArgon2idService() *Argon2idService {
+	return &Argon2idService{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (s *Argon2idService) Hash(password string) (string, error) {
+	// Never produce hashes that parseArgon2id would refuse to verify
+	if s.Parallelism < 1 || s.Iterations < 1 || s.Iterations > maxArgon2Iterations ||
+		s.Memory < 8*uint32(s.Parallelism) || s.Memory > maxArgon2Memory ||
+		s.SaltLength < minArgon2SaltLength || s.KeyLength < minArgon2KeyLength ||
+		s.SaltLength > maxArgon2FieldLength || s.KeyLength > maxArgon2FieldLength {
+		return "", errors.New("argon2id parameters out of range")
+	}
+	salt := make([]byte, s.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, s.Iterations, s.Memory, s.Parallelism, s.KeyLength)
+
+	encode := base64.RawStdEncoding.EncodeToString
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, s.Memory, s.Iterations, s.Parallelism, encode(salt), encode(key)), nil
+}
+
+func (s *Argon2idService) Compare(hashedPassword string, password string) bool {
+	params, err := parseArgon2id(hashedPassword)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1
+}
+
+func (s *Argon2idService) NeedsRehash(hashedPassword string) bool {
+	params, err := parseArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params.memory != s.Memory ||
+		params.iterations != s.Iterations ||
+		params.parallelism != s.Parallelism ||
+		uint32(len(params.salt)) != s.SaltLength ||
+		uint32(len(params.key)) != s.KeyLength
+}
+
+func (s *Argon2idService) Matches(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$argon2id$")
+}
+
+var errInvalidArgon2Hash = errors.New("invalid argon2id hash")
+
+// Bounds on the parameters read from stored hashes, so a corrupted or planted hash
+// cannot crash argon2 or exhaust memory during a login
+const (
+	maxArgon2Memory      = 1 << 20 // KiB, 1 GiB
+	maxArgon2Iterations  = 64
+	minArgon2SaltLength  = 8
+	minArgon2KeyLength   = 4
+	maxArgon2FieldLength = 1024
+)
+
+func parseArgon2id(hashedPassword string) (*argon2Params, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errInvalidArgon2Hash
+	}
+
+	params := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, errInvalidArgon2Hash
+	}
+	if params.parallelism < 1 ||
+		params.iterations < 1 || params.iterations > maxArgon2Iterations ||
+		params.memory < 8*uint32(params.parallelism) || params.memory > maxArgon2Memory {
+		return nil, errInvalidArgon2Hash
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil ||
+		len(params.salt) < minArgon2SaltLength || len(params.salt) > maxArgon2FieldLength {
+		return nil, errInvalidArgon2Hash
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil ||
+		len(params.key) < minArgon2KeyLength || len(params.key) > maxArgon2FieldLength {
+		return nil, errInvalidArgon2Hash
+	}
+	return params, nil
+}
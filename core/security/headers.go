@@ -0,0 +1,105 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CSPNonceLocalsKey holds the Content-Security-Policy nonce of the request
+const CSPNonceLocalsKey = "csp_nonce"
+
+// NoncePlaceholder is replaced in ContentSecurityPolicy by 'nonce-<value>', a fresh
+// value per request that templates read with CSPNonce
+const NoncePlaceholder = "{nonce}"
+
+// SecurityHeaders sets browser hardening headers. Empty values leave a header unset.
+type SecurityHeaders struct {
+	// HSTSMaxAge in seconds; Strict-Transport-Security is only sent over HTTPS
+	HSTSMaxAge            int
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	ContentTypeNosniff    bool
+	PermissionsPolicy     string
+}
+
+// NewSecurityHeaders returns strict defaults: one year of HSTS, a same-origin CSP
+// allowing nonce scripts, no framing and no referrer leaking across origins
+func NewSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' " + NoncePlaceholder + "; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentTypeNosniff:    true,
+	}
+}
+
+func (h *SecurityHeaders) Use() fiber.Handler {
+	hsts := h.hsts()
+	return func(c *fiber.Ctx) error {
+		if hsts != "" && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		if h.ContentSecurityPolicy != "" {
+			policy := h.ContentSecurityPolicy
+			if strings.Contains(policy, NoncePlaceholder) {
+				nonce, err := newNonce()
+				if err != nil {
+					return err
+				}
+				c.Locals(CSPNonceLocalsKey, nonce)
+				policy = strings.ReplaceAll(policy, NoncePlaceholder, "'nonce-"+nonce+"'")
+			}
+			c.Set(fiber.HeaderContentSecurityPolicy, policy)
+		}
+		if h.FrameOptions != "" {
+			c.Set(fiber.HeaderXFrameOptions, h.FrameOptions)
+		}
+		if h.ReferrerPolicy != "" {
+			c.Set(fiber.HeaderReferrerPolicy, h.ReferrerPolicy)
+		}
+		if h.ContentTypeNosniff {
+			c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		}
+		if h.PermissionsPolicy != "" {
+			c.Set(fiber.HeaderPermissionsPolicy, h.PermissionsPolicy)
+		}
+		return c.Next()
+	}
+}
+
+func (h *SecurityHeaders) hsts() string {
+	if h.HSTSMaxAge <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.Itoa(h.HSTSMaxAge)
+	if h.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if h.HSTSPreload {
+		value += "; preload"
+	}
+	return value
+}
+
+// CSPNonce returns the nonce to put on inline <script nonce="..."> tags of the response
+func CSPNonce(c *fiber.Ctx) string {
+	nonce, _ := c.Locals(CSPNonceLocalsKey).(string)
+	return nonce
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
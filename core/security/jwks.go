@@ -0,0 +1,329 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK describes the public part of a key
+func PublicJWK(key *SigningKey) (JWK, error) {
+	jwk, err := publicJWK(key.Public)
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk.Kid = key.ID
+	jwk.Use = "sig"
+	jwk.Alg = key.Method.Alg()
+	return jwk, nil
+}
+
+func publicJWK(public crypto.PublicKey) (JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}, nil
+	case *ecdsa.PublicKey:
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return JWK{}, err
+		}
+		// Uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		return JWK{Kty: "EC", Crv: key.Curve.Params().Name, X: encode(point[:size]), Y: encode(point[size:])}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: encode(key)}, nil
+	case *ed25519.PublicKey:
+		return publicJWK(*key)
+	}
+	return JWK{}, fmt.Errorf("unsupported key type %T", public)
+}
+
+// Thumbprint computes the RFC 7638 SHA-256 thumbprint of a public key
+func Thumbprint(public crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK(public)
+	if err != nil {
+		return "", err
+	}
+
+	// Required members only, in lexicographic order
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	encoded, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// PublicKey decodes the key described by the JWK
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch j.Kty {
+	case "RSA":
+		n, err := decode(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid n: %w", j.Kid, err)
+		}
+		e, err := decode(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid e: %w", j.Kid, err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var (
+			curve     elliptic.Curve
+			ecdhCurve ecdh.Curve
+		)
+		switch j.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %s", j.Kid, j.Crv)
+		}
+		x, errX := decode(j.X)
+		y, errY := decode(j.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwk %s: invalid coordinates", j.Kid)
+		}
+		// Reject points that are not on the curve
+		if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("jwk %s: %w", j.Kid, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(j.X)
+		if err != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid Ed25519 key", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("jwk %s: unsupported key type %s", j.Kid, j.Kty)
+}
+
+// JWKS returns the public keys of the set
+func (ks *KeySet) JWKS() (JWKS, error) {
+	document := JWKS{Keys: []JWK{}}
+	for _, key := range ks.Keys() {
+		jwk, err := PublicJWK(key)
+		if err != nil {
+			return JWKS{}, err
+		}
+		document.Keys = append(document.Keys, jwk)
+	}
+	return document, nil
+}
+
+// JWKSHandler serves the public keys of ks, typically at /.well-known/jwks.json
+func JWKSHandler(ks *KeySet) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		document, err := ks.JWKS()
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		c.Set(fiber.HeaderContentType, "application/jwk-set+json")
+		return c.JSON(document, "application/jwk-set+json")
+	}
+}
+
+// RemoteKeySet verifies tokens with keys published as a JWKS document at URL.
+// Keys are cached for TTL; an unknown kid triggers a refresh, at most once per MinRefreshInterval.
+// Concurrent lookups share a single in-flight fetch and never wait on it for cached keys.
+type RemoteKeySet struct {
+	URL                string
+	Client             *http.Client
+	TTL                time.Duration
+	MinRefreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]*SigningKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetches     singleflight.Group
+}
+
+// errRefreshThrottled reports a refresh skipped because of MinRefreshInterval
+var errRefreshThrottled = errors.New("jwks refresh throttled")
+
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		URL:                url,
+		Client:             &http.Client{Timeout: 10 * time.Second},
+		TTL:                10 * time.Minute,
+		MinRefreshInterval: time.Minute,
+	}
+}
+
+func (r *RemoteKeySet) ResolveKey(ctx context.Context, kid string) (*SigningKey, error) {
+	key, found, fresh := r.cached(kid)
+	if found && fresh {
+		return key, nil
+	}
+
+	// The fetch outlives a caller that gives up, so other waiters still get its result
+	result := r.fetches.DoChan("refresh", func() (any, error) {
+		return nil, r.throttledRefresh(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		if found {
+			return key, nil
+		}
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil && !errors.Is(res.Err, errRefreshThrottled) {
+			// Keep serving cached keys when the JWKS endpoint is unavailable
+			if found {
+				return key, nil
+			}
+			return nil, res.Err
+		}
+	}
+
+	if key, found, _ = r.cached(kid); !found {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// cached looks kid up without waiting for an in-flight refresh
+func (r *RemoteKeySet) cached(kid string) (key *SigningKey, found, fresh bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, found = r.keys[kid]
+	return key, found, time.Since(r.fetchedAt) < r.TTL
+}
+
+// throttledRefresh fetches the document unless one was attempted within MinRefreshInterval
+func (r *RemoteKeySet) throttledRefresh(ctx context.Context) error {
+	r.mu.Lock()
+	if time.Since(r.attemptedAt) < r.MinRefreshInterval {
+		r.mu.Unlock()
+		return errRefreshThrottled
+	}
+	r.attemptedAt = time.Now()
+	r.mu.Unlock()
+
+	return r.refresh(ctx)
+}
+
+func (r *RemoteKeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var document JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		key, err := NewVerificationKey(jwk.Kid, public)
+		if err != nil {
+			continue
+		}
+		// RSA keys may be published for RS384, PS256, ...; other key types imply their algorithm
+		if _, isRSA := public.(*rsa.PublicKey); isRSA {
+			switch method := jwt.GetSigningMethod(jwk.Alg).(type) {
+			case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+				key.Method = method
+			}
+		}
+		keys[key.ID] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("jwks contains no usable signing keys")
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
@@ -1,29 +1,82 @@
 package security
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/viper"
 )
 
+// JwtService signs and verifies JWTs. With Keys or Resolver configured it uses
+// asymmetric keys (RS256/ES256/EdDSA) selected by the kid header; otherwise it
+// falls back to HS256 with SecretKey.
 type JwtService struct {
 	SecretKey string
+	// Keys signs new tokens with its active key and verifies tokens issued by any of its keys
+	Keys *KeySet
+	// Resolver verifies tokens issued elsewhere, e.g. a RemoteKeySet reading a JWKS URL
+	Resolver KeyResolver
+	// Revocations is consulted by AuthGuard; NewTokenService sets it
+	Revocations RevocationChecker
 }
 
+// NewJwtService reads JWT_SECRET, the asymmetric keys described in LoadKeySetFromEnv
+// and JWT_JWKS_URL. It panics when configured keys cannot be loaded.
 func NewJwtService() *JwtService {
 	secret := viper.GetString("JWT_SECRET")
 	if secret == "" {
 		secret = "default_secret_change_me"
 	}
-	return &JwtService{
+
+	keys, err := LoadKeySetFromEnv()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load JWT keys: %v", err))
+	}
+
+	service := &JwtService{
 		SecretKey: secret,
+		Keys:      keys,
 	}
+	if url := viper.GetString("JWT_JWKS_URL"); url != "" {
+		service.Resolver = NewRemoteKeySet(url)
+	}
+	return service
+}
+
+// NewJwtServiceWithKeys creates a service signing with the active key of keys
+func NewJwtServiceWithKeys(keys *KeySet) *JwtService {
+	return &JwtService{Keys: keys}
+}
+
+// asymmetric reports whether HMAC tokens must be rejected
+func (s *JwtService) asymmetric() bool {
+	return s.Keys != nil || s.Resolver != nil
 }
 
 func (s *JwtService) Sign(claims map[string]interface{}) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+	method := jwt.SigningMethod(jwt.SigningMethodHS256)
+	var signingKey any = []byte(s.SecretKey)
+
+	var kid string
+	if s.Keys == nil && s.Resolver != nil {
+		// Tokens signed with SecretKey would be rejected by this service's own Verify
+		return "", errors.New("no signing keys: a Resolver only verifies tokens issued elsewhere")
+	}
+	if s.Keys != nil {
+		active := s.Keys.Active()
+		if active == nil {
+			return "", errors.New("no active signing key")
+		}
+		method, signingKey, kid = active.Method, active.signingKey(), active.ID
+	}
+
+	token := jwt.New(method)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
 	// Copy claims
 	tokenClaims := token.Claims.(jwt.MapClaims)
@@ -36,15 +89,33 @@ func (s *JwtService) Sign(claims map[string]interface{}) (string, error) {
 		tokenClaims["exp"] = time.Now().Add(time.Hour * 72).Unix()
 	}
 
-	return token.SignedString([]byte(s.SecretKey))
+	return token.SignedString(signingKey)
 }
 
 func (s *JwtService) Verify(tokenString string) (map[string]interface{}, error) {
+	return s.VerifyCtx(context.Background(), tokenString)
+}
+
+// VerifyCtx verifies a token; ctx bounds fetching keys from a remote JWKS
+func (s *JwtService) VerifyCtx(ctx context.Context, tokenString string) (map[string]interface{}, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if !s.asymmetric() {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return []byte(s.SecretKey), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.resolveKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		// The algorithm is bound to the key, never taken from the token alone
+		if key.Method.Alg() != token.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(s.SecretKey), nil
+		return key.verificationKey(), nil
 	})
 
 	if err != nil {
@@ -57,3 +128,15 @@ func (s *JwtService) Verify(tokenString string) (map[string]interface{}, error)
 
 	return nil, errors.New("invalid token")
 }
+
+func (s *JwtService) resolveKey(ctx context.Context, kid string) (*SigningKey, error) {
+	if s.Keys != nil {
+		if key, err := s.Keys.ResolveKey(ctx, kid); err == nil {
+			return key, nil
+		}
+	}
+	if s.Resolver != nil {
+		return s.Resolver.ResolveKey(ctx, kid)
+	}
+	return nil, ErrKeyNotFound
+}
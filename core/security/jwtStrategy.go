@@ -0,0 +1,95 @@
+package security
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JwtStrategy authenticates Bearer tokens from the Authorization header and,
+// when Cookie is set, from that cookie
+type JwtStrategy struct {
+	JwtService *JwtService `inject:"type"`
+	Cookie     string
+}
+
+func NewJwtStrategy(jwtService *JwtService) *JwtStrategy {
+	return &JwtStrategy{JwtService: jwtService}
+}
+
+// FromCookie also reads the token from the named cookie
+func (s *JwtStrategy) FromCookie(name string) *JwtStrategy {
+	s.Cookie = name
+	return s
+}
+
+func (s *JwtStrategy) Name() string {
+	return "jwt"
+}
+
+func (s *JwtStrategy) Challenge() string {
+	return "Bearer"
+}
+
+func (s *JwtStrategy) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	token := bearerToken(c)
+	if token == "" && s.Cookie != "" {
+		token = c.Cookies(s.Cookie)
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := s.JwtService.VerifyCtx(c.UserContext(), token)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if revocations := s.JwtService.Revocations; revocations != nil {
+		revoked, err := revocations.IsRevoked(c.UserContext(), claims)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	// Keep the raw claims where AuthGuard puts them
+	c.Locals(UserLocalsKey, claims)
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{
+		ID:     subject,
+		Type:   "user",
+		Roles:  stringsClaim(claims["roles"]),
+		Claims: claims,
+	}, nil
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header
+func bearerToken(c *fiber.Ctx) string {
+	scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// stringsClaim reads a claim holding a list of strings (or a single space separated string)
+func stringsClaim(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return strings.Fields(v)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// ErrKeyNotFound is returned when no key matches the kid of a token
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// SigningKey is an asymmetric JWT key. Keys without a private part can only verify.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// NewSigningKey wraps an RSA (RS256), ECDSA (ES256/ES384/ES512) or Ed25519 (EdDSA)
+// private key. An empty id is replaced by the RFC 7638 thumbprint of the public key.
+func NewSigningKey(id string, private crypto.Signer) (*SigningKey, error) {
+	key, err := NewVerificationKey(id, private.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.Private = private
+	return key, nil
+}
+
+// NewVerificationKey wraps a public key used only to verify tokens, e.g. a retired
+// key still accepted during rotation
+func NewVerificationKey(id string, public crypto.PublicKey) (*SigningKey, error) {
+	method, err := methodFor(public)
+	if err != nil {
+		return nil, err
+	}
+	key := &SigningKey{ID: id, Method: method, Public: public}
+	if key.ID == "" {
+		if key.ID, err = Thumbprint(public); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// CanSign reports whether the key has a private part
+func (k *SigningKey) CanSign() bool {
+	return k.Private != nil
+}
+
+// signingKey returns the private key in the form expected by the jwt signing method
+func (k *SigningKey) signingKey() any {
+	if private, ok := k.Private.(*ed25519.PrivateKey); ok {
+		return *private
+	}
+	return k.Private
+}
+
+func (k *SigningKey) verificationKey() any {
+	if public, ok := k.Public.(*ed25519.PublicKey); ok {
+		return *public
+	}
+	return k.Public
+}
+
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("unsupported ECDSA curve %s", key.Curve.Params().Name)
+	case ed25519.PublicKey, *ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", public)
+}
+
+// KeyResolver finds the key that verifies a token with the given kid
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, kid string) (*SigningKey, error)
+}
+
+// KeySet holds the keys of a service. One key is active and signs new tokens; the
+// others keep verifying tokens issued before a rotation until they are removed.
+type KeySet struct {
+	mu     sync.RWMutex
+	keys   map[string]*SigningKey
+	active string
+}
+
+func NewKeySet(keys ...*SigningKey) *KeySet {
+	ks := &KeySet{keys: map[string]*SigningKey{}}
+	for _, key := range keys {
+		ks.Add(key)
+	}
+	return ks
+}
+
+// Add registers a key. The first key able to sign becomes the active one.
+func (ks *KeySet) Add(key *SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys[key.ID] = key
+	if ks.active == "" && key.CanSign() {
+		ks.active = key.ID
+	}
+}
+
+// Rotate adds key and makes it the active signing key, keeping the previous keys for verification
+func (ks *KeySet) Rotate(key *SigningKey) error {
+	if !key.CanSign() {
+		return fmt.Errorf("key %s has no private key", key.ID)
+	}
+	ks.Add(key)
+	return ks.SetActive(key.ID)
+}
+
+// SetActive selects the key used to sign new tokens
+func (ks *KeySet) SetActive(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("key %s has no private key", kid)
+	}
+	ks.active = kid
+	return nil
+}
+
+// Remove retires a key; tokens signed with it no longer verify
+func (ks *KeySet) Remove(kid string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	delete(ks.keys, kid)
+	if ks.active == kid {
+		ks.active = ""
+	}
+}
+
+// Active returns the signing key, or nil when the set has none
+func (ks *KeySet) Active() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[ks.active]
+}
+
+func (ks *KeySet) Key(kid string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// Keys returns every key sorted by id
+func (ks *KeySet) Keys() []*SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// ResolveKey finds a key by kid. Tokens without a kid resolve to the active key.
+func (ks *KeySet) ResolveKey(_ context.Context, kid string) (*SigningKey, error) {
+	if kid == "" {
+		if active := ks.Active(); active != nil {
+			return active, nil
+		}
+		return nil, ErrKeyNotFound
+	}
+	if key, ok := ks.Key(kid); ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// ParsePrivateKeyPEM parses a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// ParsePublicKeyPEM parses a PKIX or PKCS#1 public key, or the key of a certificate
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// LoadSigningKeyFile reads a PEM private key from path
+func LoadSigningKeyFile(kid string, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	private, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewSigningKey(kid, private)
+}
+
+// LoadVerificationKeyFile reads a PEM public key from path
+func LoadVerificationKeyFile(kid string, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	public, err := ParsePublicKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewVerificationKey(kid, public)
+}
+
+// LoadKeySetFromEnv builds a key set from the environment:
+//
+//	JWT_PRIVATE_KEY       PEM private key of the active key (\n escapes allowed)
+//	JWT_PRIVATE_KEY_FILE  path of the active private key, when JWT_PRIVATE_KEY is empty
+//	JWT_KEY_ID            kid of the active key (defaults to its thumbprint)
+//	JWT_PUBLIC_KEY_FILES  comma separated public keys still accepted, e.g. retired keys
+//
+// It returns nil when no key is configured.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	kid := viper.GetString("JWT_KEY_ID")
+	ks := NewKeySet()
+
+	if pemKey := viper.GetString("JWT_PRIVATE_KEY"); pemKey != "" {
+		private, err := ParsePrivateKeyPEM([]byte(strings.ReplaceAll(pemKey, `\n`, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+		}
+		key, err := NewSigningKey(kid, private)
+		if err != nil {
+			return nil, err
+		}
+		ks.Add(key)
+	} else if path := viper.GetString("JWT_PRIVATE_KEY_FILE"); path != "" {
+		key, err := LoadSigningKeyFile(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		ks.Add(key)
+	}
+
+	for _, path := range strings.Split(viper.GetString("JWT_PUBLIC_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := LoadVerificationKeyFile("", path)
+		if err != nil {
+			return nil, err
+		}
+		ks.Add(key)
+	}
+
+	if len(ks.Keys()) == 0 {
+		return nil, nil
+	}
+	return ks, nil
+}
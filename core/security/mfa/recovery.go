@@ -0,0 +1,117 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+)
+
+// recoveryAlphabet avoids characters easily confused when read back (0/o, 1/l/i)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes returns count codes formatted as xxxxx-xxxxx, to show the
+// user once. Store only their HashRecoveryCodes hashes.
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	for i := range codes {
+		raw, err := randomCode(10)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+	}
+	return codes, nil
+}
+
+// randomCode draws n characters of recoveryAlphabet, rejecting bytes that would
+// bias the modulo towards the first characters
+func randomCode(n int) (string, error) {
+	limit := byte(256 / len(recoveryAlphabet) * len(recoveryAlphabet))
+	code := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(code) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(code) < n {
+				code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// HashRecoveryCodes hashes codes for storage
+func HashRecoveryCodes(hasher security.HashService, codes []string) ([]string, error) {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hash, err := hasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	return hashes, nil
+}
+
+// RedeemRecoveryCode looks code up in hashes. When found, it returns the hashes
+// without it, to persist so the code cannot be used again. Redemption is a
+// read-modify-write of the caller's hashes: persist remaining with a conditional
+// update (a version column or compare-and-swap), or use RedeemRecoveryCodeOnce, so
+// two concurrent logins with the same code cannot both succeed.
+func RedeemRecoveryCode(hasher security.HashService, hashes []string, code string) ([]string, bool) {
+	i := matchRecoveryCode(hasher, hashes, code)
+	if i < 0 {
+		return hashes, false
+	}
+	return withoutHash(hashes, i), true
+}
+
+// RedeemRecoveryCodeOnce is RedeemRecoveryCode with an atomic claim of the matched
+// hash in store, so only one concurrent redemption of a code succeeds. Claims do
+// not expire; the caller still persists remaining.
+func RedeemRecoveryCodeOnce(ctx context.Context, store cache.Store, hasher security.HashService, hashes []string, code string) ([]string, bool, error) {
+	i := matchRecoveryCode(hasher, hashes, code)
+	if i < 0 {
+		return hashes, false, nil
+	}
+	claimed, err := store.Add(ctx, "mfa:recovery:"+hashes[i], true, 0)
+	if err != nil || !claimed {
+		return hashes, false, err
+	}
+	return withoutHash(hashes, i), true, nil
+}
+
+// matchRecoveryCode returns the index of the hash of code, or -1
+func matchRecoveryCode(hasher security.HashService, hashes []string, code string) int {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return -1
+	}
+	for i, hash := range hashes {
+		if hasher.Compare(hash, code) {
+			return i
+		}
+	}
+	return -1
+}
+
+func withoutHash(hashes []string, i int) []string {
+	remaining := make([]string, 0, len(hashes)-1)
+	remaining = append(remaining, hashes[:i]...)
+	return append(remaining, hashes[i+1:]...)
+}
+
+// normalizeRecoveryCode accepts codes typed in any case, with or without separators
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,178 @@
+// Package mfa adds a second authentication factor: RFC 6238 time-based one-time
+// passwords, single-use recovery codes and a guard requiring a verified factor.
+package mfa
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+)
+
+// Algorithm is the HMAC hash of the codes. Most authenticator apps only support SHA1.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+var ErrInvalidSecret = errors.New("invalid TOTP secret")
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP generates and verifies time-based one-time passwords. Skew accepts codes of
+// that many periods before and after the current one, for clock drift. With a Store,
+// each code is only accepted once, even when submitted concurrently.
+type TOTP struct {
+	Issuer    string
+	Digits    int
+	Period    time.Duration
+	Skew      int
+	Algorithm Algorithm
+	Store     cache.Store
+}
+
+// New uses the settings authenticator apps expect: 6 digits every 30 seconds with SHA1
+func New(issuer string, store cache.Store) *TOTP {
+	return &TOTP{
+		Issuer:    issuer,
+		Digits:    6,
+		Period:    30 * time.Second,
+		Skew:      1,
+		Algorithm: SHA1,
+		Store:     store,
+	}
+}
+
+// GenerateSecret returns a random 160 bit secret, base32 encoded
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth:// URI to show as a QR code when enrolling account
+func (t *TOTP) ProvisioningURI(secret string, account string) string {
+	label := account
+	if t.Issuer != "" {
+		label = t.Issuer + ":" + account
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if t.Issuer != "" {
+		query.Set("issuer", t.Issuer)
+	}
+	query.Set("algorithm", string(t.Algorithm))
+	query.Set("digits", strconv.Itoa(t.Digits))
+	query.Set("period", strconv.Itoa(int(t.Period.Seconds())))
+
+	return "otpauth://totp/" + url.PathEscape(label) + "?" + query.Encode()
+}
+
+// Code returns the code of secret at time at
+func (t *TOTP) Code(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return t.code(key, t.counter(at)), nil
+}
+
+// Verify checks code for account, whose enrolled secret is secret. Accepted codes
+// are remembered in Store for account so they cannot be replayed.
+func (t *TOTP) Verify(ctx context.Context, account string, secret string, code string) (bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return false, err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != t.Digits {
+		return false, nil
+	}
+
+	current := t.counter(time.Now())
+	for offset := -t.Skew; offset <= t.Skew; offset++ {
+		counter := current + int64(offset)
+		if subtle.ConstantTimeCompare([]byte(t.code(key, counter)), []byte(code)) == 1 {
+			return t.claim(ctx, account, counter)
+		}
+	}
+	return false, nil
+}
+
+func (t *TOTP) counter(at time.Time) int64 {
+	return at.Unix() / int64(t.Period.Seconds())
+}
+
+// code is the HOTP value (RFC 4226) of counter
+func (t *TOTP) code(key []byte, counter int64) string {
+	mac := hmac.New(t.hash(), key)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < t.Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", t.Digits, value%modulo)
+}
+
+func (t *TOTP) hash() func() hash.Hash {
+	switch t.Algorithm {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// claim atomically marks the code of counter as used; only the first caller wins
+func (t *TOTP) claim(ctx context.Context, account string, counter int64) (bool, error) {
+	if t.Store == nil {
+		return true, nil
+	}
+	// Past the skew window the code is rejected anyway
+	ttl := time.Duration(2*t.Skew+1) * t.Period
+	claimed, err := t.Store.Add(ctx, t.replayKey(account, counter), true, ttl)
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+func (t *TOTP) replayKey(account string, counter int64) string {
+	return "mfa:totp:" + account + ":" + strconv.FormatInt(counter, 10)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	key, err := secretEncoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
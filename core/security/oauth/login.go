@@ -0,0 +1,246 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// UserResolver finds or creates the app user of an external identity, e.g. in the
+// user service, and returns the subject and claims of the app tokens
+type UserResolver func(ctx context.Context, identity *Identity) (subject string, claims map[string]any, err error)
+
+// pendingLogin is the server side state of a login between redirect and callback
+type pendingLogin struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+// Login runs the authorization code flow with the providers it knows:
+//
+//	app.Get("/auth/:provider", login.Redirect())
+//	app.Get("/auth/:provider/callback", login.Callback())
+//
+// The state is kept in Store and bound to the browser by a cookie. On callback the
+// app tokens are issued by Tokens (access and refresh) or, without it, Jwt.
+type Login struct {
+	Providers    map[string]*Provider
+	Store        cache.Store
+	ResolveUser  UserResolver
+	Tokens       *security.TokenService
+	Jwt          *security.JwtService
+	StateTTL     time.Duration
+	CookieName   string
+	SecureCookie bool
+	// OnSuccess replaces the default JSON response, e.g. to set a cookie and redirect
+	OnSuccess func(c *fiber.Ctx, tokens *security.TokenPair) error
+}
+
+// NewLogin issues app tokens with tokens. Logins issuing bare access tokens set Jwt
+// on a Login built directly instead.
+func NewLogin(store cache.Store, resolveUser UserResolver, tokens *security.TokenService, providers ...*Provider) (*Login, error) {
+	switch {
+	case store == nil:
+		return nil, errors.New("oauth: login requires a store")
+	case resolveUser == nil:
+		return nil, errors.New("oauth: login requires a user resolver")
+	case tokens == nil:
+		return nil, errors.New("oauth: login requires a token service")
+	}
+	l := &Login{
+		Providers:    map[string]*Provider{},
+		Store:        store,
+		ResolveUser:  resolveUser,
+		Tokens:       tokens,
+		StateTTL:     10 * time.Minute,
+		CookieName:   "oauth_state",
+		SecureCookie: true,
+	}
+	for _, p := range providers {
+		l.Providers[p.Name] = p
+	}
+	return l, nil
+}
+
+// Redirect sends the user to the provider named by the :provider route parameter
+func (l *Login) Redirect() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		provider, ok := l.Providers[c.Params("provider")]
+		if !ok {
+			return core.NotFound("Unknown login provider")
+		}
+
+		state, err := randomString()
+		if err != nil {
+			return err
+		}
+		pending := pendingLogin{Provider: provider.Name}
+		if pending.Verifier, err = randomString(); err != nil {
+			return err
+		}
+		if pending.Nonce, err = randomString(); err != nil {
+			return err
+		}
+		if err := l.Store.Set(c.UserContext(), stateKey(state), pending, l.StateTTL); err != nil {
+			return err
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     l.CookieName,
+			Value:    state,
+			Path:     "/",
+			Expires:  time.Now().Add(l.StateTTL),
+			Secure:   l.SecureCookie,
+			HTTPOnly: true,
+			// Lax so the cookie comes back with the provider's top level redirect
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+		return c.Redirect(provider.AuthCodeURL(state, pending.Nonce, pending.Verifier), fiber.StatusFound)
+	}
+}
+
+// Callback completes the login and issues the app tokens
+func (l *Login) Callback() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if reason := c.Query("error"); reason != "" {
+			// The reason comes from the query string, so it is logged rather than echoed
+			logger.Log.Warn("OAuth login not completed",
+				zap.String("provider", c.Params("provider")),
+				zap.String("error", reason),
+				zap.String("description", c.Query("error_description")))
+			return core.Unauthorized("Login was not completed")
+		}
+
+		state := c.Query("state")
+		cookie := c.Cookies(l.CookieName)
+		c.ClearCookie(l.CookieName)
+		if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+			return core.Unauthorized("Invalid login state")
+		}
+
+		var pending pendingLogin
+		err := l.Store.Get(c.UserContext(), stateKey(state), &pending)
+		if errors.Is(err, cache.ErrNotFound) {
+			return core.Unauthorized("Login expired, please try again")
+		}
+		if err != nil {
+			return err
+		}
+		// The state is single use; the atomic claim stops concurrent callbacks that
+		// both read it before either forgets it
+		claimed, err := l.Store.Add(c.UserContext(), stateKey(state)+":used", true, l.StateTTL)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return core.Unauthorized("Invalid login state")
+		}
+		if err := l.Store.Forget(c.UserContext(), stateKey(state)); err != nil {
+			return err
+		}
+
+		provider, ok := l.Providers[c.Params("provider")]
+		if !ok || provider.Name != pending.Provider {
+			return core.Unauthorized("Invalid login state")
+		}
+
+		identity, err := l.authenticate(c.UserContext(), provider, c.Query("code"), pending)
+		if err != nil {
+			logger.Log.Warn("OAuth login failed", zap.String("provider", provider.Name), zap.Error(err))
+			return core.Unauthorized("Login failed").WithCause(err)
+		}
+
+		subject, claims, err := l.ResolveUser(c.UserContext(), identity)
+		if err != nil {
+			return err
+		}
+		tokens, err := l.issue(c.UserContext(), subject, claims)
+		if err != nil {
+			return err
+		}
+
+		if l.OnSuccess != nil {
+			return l.OnSuccess(c, tokens)
+		}
+		return core.Respond(c, core.HttpSuccessWithData("Logged in", core.HttpStatusOK, tokens))
+	}
+}
+
+// authenticate exchanges the code and gathers the claims of the ID token and user info
+func (l *Login) authenticate(ctx context.Context, provider *Provider, code string, pending pendingLogin) (*Identity, error) {
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+	token, err := provider.Exchange(ctx, code, pending.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any{}
+	if provider.Issuer != "" {
+		if token.IDToken == "" {
+			return nil, errors.New("provider returned no id token")
+		}
+		if claims, err = provider.VerifyIDToken(ctx, token.IDToken, pending.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	if provider.UserInfoURL != "" {
+		info, err := provider.UserInfo(ctx, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		// User info must describe the ID token's user (OIDC Core 5.3.2)
+		if sub, ok := claims["sub"]; ok && info["sub"] != sub {
+			return nil, errors.New("user info subject does not match the id token")
+		}
+		for k, v := range info {
+			claims[k] = v
+		}
+	}
+	return provider.Identity(claims)
+}
+
+func (l *Login) issue(ctx context.Context, subject string, claims map[string]any) (*security.TokenPair, error) {
+	if l.Tokens != nil {
+		return l.Tokens.Issue(ctx, subject, claims)
+	}
+	if l.Jwt == nil {
+		return nil, errors.New("oauth: login has neither Tokens nor Jwt to issue tokens")
+	}
+
+	accessClaims := make(map[string]interface{}, len(claims)+1)
+	for k, v := range claims {
+		accessClaims[k] = v
+	}
+	accessClaims["sub"] = subject
+	accessToken, err := l.Jwt.Sign(accessClaims)
+	if err != nil {
+		return nil, err
+	}
+	return &security.TokenPair{AccessToken: accessToken, TokenType: "Bearer"}, nil
+}
+
+func stateKey(state string) string {
+	return "oauth:state:" + state
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,278 @@
+// Package oauth signs users in with external OAuth2 / OpenID Connect providers
+// using the authorization code flow with PKCE, then issues the app's own tokens.
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core/security"
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// defaultHTTPClient bounds provider calls made without a HTTPClient
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Provider holds the client registration and endpoints of an identity provider.
+// Fill the endpoints by hand for plain OAuth2 providers, or use Discover for OIDC.
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// Issuer and JWKSURL enable ID token verification (OpenID Connect)
+	Issuer  string
+	JWKSURL string
+
+	// MapClaims turns the ID token and user info claims into an Identity.
+	// It defaults to the standard OIDC claims (sub, email, name, ...).
+	MapClaims func(claims map[string]any) (*Identity, error)
+	// HTTPClient calls the provider; it defaults to a client with a 10 second timeout
+	HTTPClient *http.Client
+
+	verifierOnce sync.Once
+	verifier     *security.JwtService
+}
+
+// Identity is the user as known by the provider
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+	Claims        map[string]any
+}
+
+// Token is the token endpoint response
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+	Scope        string `json:"scope"`
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Discover fills the endpoints of an OpenID Connect provider from its
+// <issuer>/.well-known/openid-configuration document, and the default scopes
+func (p *Provider) Discover(ctx context.Context, issuer string) error {
+	var doc discoveryDocument
+	if err := p.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return fmt.Errorf("discover %s: %w", issuer, err)
+	}
+	// The document must be about the issuer it was fetched from (OIDC Discovery 4.3)
+	if doc.Issuer != issuer {
+		return fmt.Errorf("discover %s: issuer mismatch %q", issuer, doc.Issuer)
+	}
+
+	p.Issuer = doc.Issuer
+	p.AuthURL = doc.AuthorizationEndpoint
+	p.TokenURL = doc.TokenEndpoint
+	p.UserInfoURL = doc.UserinfoEndpoint
+	p.JWKSURL = doc.JwksURI
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	return nil
+}
+
+// AuthCodeURL returns where to send the user, with state, nonce and the PKCE S256
+// challenge of verifier
+func (p *Provider) AuthCodeURL(state string, nonce string, verifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectURL)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	if nonce != "" {
+		query.Set("nonce", nonce)
+	}
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.AuthURL + separator + query.Encode()
+}
+
+// Exchange trades an authorization code and its PKCE verifier for tokens
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	var token Token
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("exchange code: no access token in response")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience and nonce of an ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, idToken string, nonce string) (map[string]any, error) {
+	if p.JWKSURL == "" {
+		return nil, fmt.Errorf("%w: provider %s has no JWKS URL", ErrInvalidIDToken, p.Name)
+	}
+	p.verifierOnce.Do(func() {
+		keys := security.NewRemoteKeySet(p.JWKSURL)
+		if p.HTTPClient != nil {
+			keys.Client = p.HTTPClient
+		}
+		p.verifier = &security.JwtService{Resolver: keys}
+	})
+
+	claims, err := p.verifier.VerifyCtx(ctx, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if iss, _ := claims["iss"].(string); iss != p.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, iss)
+	}
+	if !audienceContains(claims["aud"], p.ClientID) {
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// UserInfo fetches the claims of the user info endpoint
+func (p *Provider) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	claims := map[string]any{}
+	if err := p.getJSON(ctx, p.UserInfoURL, accessToken, &claims); err != nil {
+		return nil, fmt.Errorf("fetch user info: %w", err)
+	}
+	return claims, nil
+}
+
+// Identity maps claims with MapClaims, or the standard OIDC claims
+func (p *Provider) Identity(claims map[string]any) (*Identity, error) {
+	if p.MapClaims != nil {
+		identity, err := p.MapClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+		identity.Provider = p.Name
+		if identity.Claims == nil {
+			identity.Claims = claims
+		}
+		return identity, nil
+	}
+
+	identity := &Identity{Provider: p.Name, Claims: claims}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Name, _ = claims["name"].(string)
+	identity.Picture, _ = claims["picture"].(string)
+	if identity.Subject == "" {
+		return nil, errors.New("provider returned no subject")
+	}
+	return identity, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, accessToken string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return p.do(req, dest)
+}
+
+func (p *Provider) do(req *http.Request, dest any) error {
+	client := p.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, dest)
+}
+
+// CodeChallenge is the PKCE S256 challenge of verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func audienceContains(aud any, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []any:
+		for _, item := range v {
+			if item == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
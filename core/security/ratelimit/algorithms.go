@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FixedWindow allows Limit requests per Window, resetting at window boundaries
+type FixedWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func NewFixedWindow(limit int, window time.Duration) *FixedWindow {
+	return &FixedWindow{Limit: limit, Window: window}
+}
+
+func (a *FixedWindow) Allow(ctx context.Context, store Store, key string, now time.Time) (Result, error) {
+	start := now.Truncate(a.Window)
+	count, err := store.Increment(ctx, key+":"+strconv.FormatInt(start.UnixNano(), 10), a.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	reset := start.Add(a.Window).Sub(now)
+	result := Result{
+		Allowed:   count <= int64(a.Limit),
+		Limit:     a.Limit,
+		Remaining: max(a.Limit-int(count), 0),
+		Reset:     reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+// SlidingWindow allows Limit requests in any Window long period. The count is
+// estimated from the current and previous fixed windows, weighting the previous
+// one by how much of it still overlaps the sliding window.
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{Limit: limit, Window: window}
+}
+
+func (a *SlidingWindow) Allow(ctx context.Context, store Store, key string, now time.Time) (Result, error) {
+	start := now.Truncate(a.Window)
+	current, err := store.Increment(ctx, key+":"+strconv.FormatInt(start.UnixNano(), 10), 2*a.Window)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := store.Get(ctx, key+":"+strconv.FormatInt(start.Add(-a.Window).UnixNano(), 10))
+	if err != nil {
+		return Result{}, err
+	}
+
+	overlap := 1 - float64(now.Sub(start))/float64(a.Window)
+	estimated := float64(previous)*overlap + float64(current)
+
+	result := Result{
+		Allowed:   estimated <= float64(a.Limit),
+		Limit:     a.Limit,
+		Remaining: max(a.Limit-int(math.Ceil(estimated)), 0),
+		// Requests of the current window weigh on the sliding window until the end of the next one
+		Reset: start.Add(2 * a.Window).Sub(now),
+	}
+	if !result.Allowed {
+		result.RetryAfter = a.retryAfter(previous, current, now.Sub(start))
+	}
+	return result, nil
+}
+
+// retryAfter estimates when the weighted count of the previous window has decayed
+// enough for one more request, or the next window starts
+func (a *SlidingWindow) retryAfter(previous int64, current int64, elapsed time.Duration) time.Duration {
+	untilNextWindow := a.Window - elapsed
+	if previous == 0 {
+		return untilNextWindow
+	}
+	// previous * (1 - t/window) + current <= limit  =>  t >= window * (1 - (limit-current)/previous)
+	needed := time.Duration(float64(a.Window) * (1 - float64(int64(a.Limit)-current)/float64(previous)))
+	if needed <= elapsed || needed > a.Window {
+		return untilNextWindow
+	}
+	return needed - elapsed
+}
+
+// TokenBucket holds up to Capacity tokens, refilled at Capacity per Period. Each
+// request takes a token, allowing bursts up to Capacity.
+type TokenBucket struct {
+	Capacity int
+	Period   time.Duration
+}
+
+func NewTokenBucket(capacity int, period time.Duration) *TokenBucket {
+	return &TokenBucket{Capacity: capacity, Period: period}
+}
+
+func (a *TokenBucket) Allow(ctx context.Context, store Store, key string, now time.Time) (Result, error) {
+	rate := float64(a.Capacity) / a.Period.Seconds()
+	tokens, allowed, err := store.TakeToken(ctx, key, a.Capacity, rate, now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     a.Capacity,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(a.Capacity) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,162 @@
+// Package ratelimit throttles requests per client with fixed window, sliding window
+// or token bucket algorithms, backed by memory or Redis.
+//
+// Limiters fail closed: while the store is unavailable requests are rejected with a
+// 429, so an outage cannot lift brute-force protection. Throttling a login route:
+//
+//	limiter := ratelimit.New(ratelimit.NewSlidingWindow(5, time.Minute), ratelimit.NewRedisStore(client), ratelimit.ByIP)
+//	limiter.Prefix = "ratelimit:login:"
+//	app.Post("/auth/login", limiter.Use(), authController.Login)
+//
+// Set FailOpen on limiters of endpoints where availability matters more than throttling.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/logger"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Result describes the state of a client's quota after a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the quota is fully available again
+	Reset time.Duration
+	// RetryAfter is the time until the next request may be allowed, when denied
+	RetryAfter time.Duration
+}
+
+// Algorithm decides whether a request identified by key is allowed
+type Algorithm interface {
+	Allow(ctx context.Context, store Store, key string, now time.Time) (Result, error)
+}
+
+// KeyFunc identifies the client a request is counted against
+type KeyFunc func(c *fiber.Ctx) string
+
+// ByIP counts requests per client IP
+func ByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// ByUser counts requests per authenticated user (the Principal or the AuthGuard
+// claims subject), falling back to the client IP for anonymous requests
+func ByUser(c *fiber.Ctx) string {
+	if principal := security.CurrentUser(c); principal != nil && principal.ID != "" {
+		return "user:" + principal.ID
+	}
+	if claims, ok := c.Locals(security.UserLocalsKey).(map[string]interface{}); ok {
+		if subject, ok := claims["sub"].(string); ok && subject != "" {
+			return "user:" + subject
+		}
+	}
+	return ByIP(c)
+}
+
+// ByHeader counts requests per value of a header, e.g. an API key id
+func ByHeader(name string) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		if value := c.Get(name); value != "" {
+			return "header:" + value
+		}
+		return ByIP(c)
+	}
+}
+
+// Limiter applies an algorithm to requests. Use it as middleware with Use(), or as
+// a per-route core.Guard. Requests are counted under Prefix + Key(c), so give each
+// protected route its own Prefix when limits must not be shared.
+type Limiter struct {
+	Algorithm Algorithm
+	Store     Store
+	Key       KeyFunc
+	Prefix    string
+	Message   string
+	// FailOpen allows requests when the store is unavailable instead of rejecting
+	// them. Store errors are logged either way.
+	FailOpen bool
+}
+
+// storeErrorRetryAfter is suggested to clients rejected because the store failed
+const storeErrorRetryAfter = time.Second
+
+func New(algorithm Algorithm, store Store, key KeyFunc) *Limiter {
+	if key == nil {
+		key = ByIP
+	}
+	return &Limiter{
+		Algorithm: algorithm,
+		Store:     store,
+		Key:       key,
+		Prefix:    "ratelimit:",
+		Message:   "Too many requests, please try again later",
+	}
+}
+
+// Check counts the request and writes the RateLimit-* (and Retry-After) headers
+func (l *Limiter) Check(c *fiber.Ctx) (Result, error) {
+	result, err := l.Algorithm.Allow(c.UserContext(), l.Store, l.Prefix+l.Key(c), time.Now())
+	if err != nil {
+		logger.Log.Error("Rate limit store failed", zap.String("path", c.Path()), zap.Error(err))
+		result = Result{Allowed: l.FailOpen, Limit: quota(l.Algorithm)}
+		if !result.Allowed {
+			result.Reset, result.RetryAfter = storeErrorRetryAfter, storeErrorRetryAfter
+		}
+	}
+
+	if result.Limit > 0 {
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	}
+	if !result.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+	return result, err
+}
+
+// quota returns the request limit of the built-in algorithms, 0 when unknown
+func quota(algorithm Algorithm) int {
+	switch a := algorithm.(type) {
+	case *FixedWindow:
+		return a.Limit
+	case *SlidingWindow:
+		return a.Limit
+	case *TokenBucket:
+		return a.Capacity
+	}
+	return 0
+}
+
+// Use rejects requests over the limit with a 429
+func (l *Limiter) Use() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, _ := l.Check(c)
+		if !result.Allowed {
+			return core.TooManyRequests(l.Message)
+		}
+		return c.Next()
+	}
+}
+
+// CanActivate lets the limiter guard a single route; denied requests still get Retry-After
+func (l *Limiter) CanActivate(c *fiber.Ctx) bool {
+	result, _ := l.Check(c)
+	return result.Allowed
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
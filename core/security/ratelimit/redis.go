@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore shares counters between instances through Redis. Pass the client of
+// cache.RedisStore (via its Client method) to reuse the cache connection pool.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+var incrementScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// takeTokenScript stores the bucket as a hash of tokens and last refill time (ms)
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts) / 1000
+tokens = math.min(capacity, tokens + elapsed * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(math.max(now, ts)))
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate * 1000))
+return {tostring(tokens), allowed}
+`)
+
+func (s *RedisStore) Increment(ctx context.Context, key string, expiry time.Duration) (int64, error) {
+	return incrementScript.Run(ctx, s.client, []string{key}, expiry.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	count, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (s *RedisStore) TakeToken(ctx context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error) {
+	reply, err := takeTokenScript.Run(ctx, s.client, []string{key}, capacity, rate, now.UnixMilli()).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(reply) != 2 {
+		return 0, false, errors.New("unexpected token bucket reply")
+	}
+
+	raw, _ := reply[0].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	allowed, _ := reply[1].(int64)
+	return tokens, allowed == 1, nil
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps rate limit counters. Operations must be atomic per key so that
+// concurrent requests (or instances, for shared stores) are all counted.
+type Store interface {
+	// Increment adds one to key, creating it with the given expiry, and returns the new count
+	Increment(ctx context.Context, key string, expiry time.Duration) (int64, error)
+	// Get returns the count of key, 0 when missing
+	Get(ctx context.Context, key string) (int64, error)
+	// TakeToken refills the bucket of key at rate tokens per second up to capacity,
+	// then takes one token if available. It returns the tokens left and whether one was taken.
+	TakeToken(ctx context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error)
+}
+
+// MemoryStore keeps counters in process memory; limits are per instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type counter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters:  map[string]*counter{},
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, expiry time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.counters[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = &counter{expiresAt: now.Add(expiry)}
+		s.counters[key] = entry
+	}
+	entry.count++
+	return entry.count, nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.counters[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return 0, nil
+	}
+	return entry.count, nil
+}
+
+func (s *MemoryStore) TakeToken(_ context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(capacity), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(capacity), b.tokens+elapsed*rate)
+		b.updatedAt = now
+	}
+	// A bucket untouched until it is full again carries no state
+	b.expiresAt = now.Add(seconds(float64(capacity) / rate))
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// sweep drops expired entries at most once a minute
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.counters {
+		if now.After(entry.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+	for key, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("signature expired")
+	ErrInvalidTTL       = errors.New("signature ttl must not be negative")
+)
+
+// NoExpiry signs payloads and URLs that stay valid until the key is retired
+const NoExpiry time.Duration = 0
+
+// Signer produces HMAC-SHA256 signed payloads and URLs that optionally expire.
+// The first key signs; all keys verify, so a previous key can be kept during rotation.
+type Signer struct {
+	Keys [][]byte
+}
+
+func NewSigner(key []byte, previous ...[]byte) *Signer {
+	return &Signer{Keys: append([][]byte{key}, previous...)}
+}
+
+// LoadSignerFromEnv reads SIGNING_KEYS, a comma separated list of base64 keys
+func LoadSignerFromEnv() (*Signer, error) {
+	var keys [][]byte
+	for _, encoded := range strings.Split(viper.GetString("SIGNING_KEYS"), ",") {
+		if encoded = strings.TrimSpace(encoded); encoded == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return &Signer{Keys: keys}, nil
+}
+
+// Sign returns payload with its expiry and signature, as
+// base64url(payload).expiry.base64url(mac). A ttl of NoExpiry never expires;
+// negative ttls are rejected.
+func (s *Signer) Sign(payload []byte, ttl time.Duration) (string, error) {
+	if ttl < 0 {
+		return "", ErrInvalidTTL
+	}
+	var expires int64
+	if ttl != NoExpiry {
+		expires = time.Now().Add(ttl).Unix()
+	}
+	message := base64.RawURLEncoding.EncodeToString(payload) + "." + strconv.FormatInt(expires, 10)
+	return message + "." + base64.RawURLEncoding.EncodeToString(s.mac(s.Keys[0], message)), nil
+}
+
+// Verify checks a token of Sign and returns its payload
+func (s *Signer) Verify(token string) ([]byte, error) {
+	cut := strings.LastIndexByte(token, '.')
+	if cut < 0 {
+		return nil, ErrInvalidSignature
+	}
+	message := token[:cut]
+	if !s.valid(message, token[cut+1:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	encoded, expiry, _ := strings.Cut(message, ".")
+	if err := checkExpiry(expiry); err != nil {
+		return nil, err
+	}
+	return base64.RawURLEncoding.DecodeString(encoded)
+}
+
+// SignURL adds expires and signature query parameters to rawURL. Only the path and
+// query are signed, so links stay valid behind proxies rewriting the host.
+// A ttl of NoExpiry never expires; negative ttls are rejected.
+func (s *Signer) SignURL(rawURL string, ttl time.Duration) (string, error) {
+	if ttl < 0 {
+		return "", ErrInvalidTTL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Del("signature")
+	query.Del("expires")
+	if ttl != NoExpiry {
+		query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	}
+	u.RawQuery = query.Encode()
+
+	signature := base64.RawURLEncoding.EncodeToString(s.mac(s.Keys[0], signedPart(u)))
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += "signature=" + signature
+	return u.String(), nil
+}
+
+// VerifyURL checks a URL produced by SignURL
+func (s *Signer) VerifyURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	query := u.Query()
+	signature := query.Get("signature")
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+	query.Del("signature")
+	u.RawQuery = query.Encode()
+
+	if !s.valid(signedPart(u), signature) {
+		return ErrInvalidSignature
+	}
+	return checkExpiry(query.Get("expires"))
+}
+
+// TemporaryURL returns a signed URL to path on disk, valid for ttl. Serve the files
+// behind a SignedURLGuard.
+func (s *Signer) TemporaryURL(disk storage.Disk, path string, ttl time.Duration) (string, error) {
+	return s.SignURL(disk.URL(path), ttl)
+}
+
+func (s *Signer) valid(message string, signature string) bool {
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	for _, key := range s.Keys {
+		if hmac.Equal(mac, s.mac(key, message)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Signer) mac(key []byte, message string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+func signedPart(u *url.URL) string {
+	return u.EscapedPath() + "?" + u.RawQuery
+}
+
+// checkExpiry accepts an empty or zero expiry as never expiring
+func checkExpiry(expiry string) error {
+	if expiry == "" || expiry == "0" {
+		return nil
+	}
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// SignedURLGuard only allows requests to URLs signed by Signer, e.g. email
+// verification links or temporary file links
+type SignedURLGuard struct {
+	Signer *Signer
+}
+
+func (g *SignedURLGuard) CanActivate(c *fiber.Ctx) bool {
+	return g.Signer.VerifyURL(c.OriginalURL()) == nil
+}
+
+// Use rejects invalid links with a 403 and expired ones with a 410
+func (g *SignedURLGuard) Use() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch err := g.Signer.VerifyURL(c.OriginalURL()); {
+		case errors.Is(err, ErrSignatureExpired):
+			return core.Gone("This link has expired")
+		case err != nil:
+			return core.Forbidden("Invalid signature")
+		}
+		return c.Next()
+	}
+}
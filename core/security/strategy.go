@@ -0,0 +1,122 @@
+package security
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrincipalLocalsKey is the locals key holding the authenticated Principal
+const PrincipalLocalsKey = "principal"
+
+var (
+	// ErrNoCredentials is returned by strategies when the request carries none of
+	// their credentials, letting the next strategy try
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when credentials are present but wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID       string
+	Type     string // e.g. "user", "api_key", "service"
+	Strategy string
+	Roles    []string
+	Claims   map[string]any
+}
+
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Strategy authenticates a request from one kind of credentials
+type Strategy interface {
+	Name() string
+	// Authenticate returns ErrNoCredentials when the request has none of the
+	// strategy's credentials and ErrInvalidCredentials when they are rejected
+	Authenticate(c *fiber.Ctx) (*Principal, error)
+}
+
+// Challenger strategies describe themselves in the WWW-Authenticate header of 401 responses
+type Challenger interface {
+	Challenge() string
+}
+
+// CurrentUser returns the principal authenticated for the request, or nil
+func CurrentUser(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals(PrincipalLocalsKey).(*Principal)
+	return principal
+}
+
+// Authenticator tries its strategies in order. The first strategy finding
+// credentials decides: valid credentials authenticate the request, invalid ones
+// reject it without falling back to the next strategy. Rejected credentials are
+// recorded as "auth.failed" when Auditor is set.
+type Authenticator struct {
+	Strategies []Strategy
+	Auditor    *Auditor
+}
+
+// Authenticate builds an Authenticator usable as a core.Guard or core.Middleware, e.g.
+//
+//	security.Authenticate(apiKeys, jwtStrategy).Use()
+func Authenticate(strategies ...Strategy) *Authenticator {
+	return &Authenticator{Strategies: strategies}
+}
+
+// Authenticate runs the strategies and stores the principal in locals
+func (a *Authenticator) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	for _, strategy := range a.Strategies {
+		principal, err := strategy.Authenticate(c)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			a.Auditor.Record(c, "auth.failed", c.OriginalURL(), AuditDenied, map[string]any{"strategy": strategy.Name()})
+			return nil, err
+		}
+		if principal.Strategy == "" {
+			principal.Strategy = strategy.Name()
+		}
+		c.Locals(PrincipalLocalsKey, principal)
+		return principal, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+func (a *Authenticator) CanActivate(c *fiber.Ctx) bool {
+	_, err := a.Authenticate(c)
+	return err == nil
+}
+
+// Use rejects unauthenticated requests with a 401 carrying the strategies' challenges
+func (a *Authenticator) Use() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := a.Authenticate(c); err != nil {
+			if challenges := a.challenges(); challenges != "" {
+				c.Set(fiber.HeaderWWWAuthenticate, challenges)
+			}
+			return core.Unauthorized("Unauthorized").WithCause(err)
+		}
+		return c.Next()
+	}
+}
+
+func (a *Authenticator) challenges() string {
+	var challenges []string
+	for _, strategy := range a.Strategies {
+		if challenger, ok := strategy.(Challenger); ok {
+			challenges = append(challenges, challenger.Challenge())
+		}
+	}
+	return strings.Join(challenges, ", ")
+}
@@ -0,0 +1,395 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused means an already rotated refresh token was presented again;
+	// the whole token family has been revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// RefreshSession is the server side state of a refresh token. Every token obtained
+// by rotating the refresh token of a login shares the same Family.
+type RefreshSession struct {
+	Family    string         `json:"family"`
+	Subject   string         `json:"subject"`
+	Claims    map[string]any `json:"claims,omitempty"`
+	IssuedAt  time.Time      `json:"issued_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// TokenStore persists refresh sessions and revocations. Entries may be dropped after until.
+type TokenStore interface {
+	// SaveRefresh stores a session under the hash of its refresh token
+	SaveRefresh(ctx context.Context, tokenHash string, session RefreshSession) error
+	// FindRefresh returns ErrInvalidRefreshToken for unknown tokens
+	FindRefresh(ctx context.Context, tokenHash string) (*RefreshSession, error)
+	// MarkRefreshUsed atomically marks a refresh token as rotated and reports
+	// whether this call did so; false means it was already used
+	MarkRefreshUsed(ctx context.Context, tokenHash string, until time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, family string, until time.Time) error
+	IsFamilyRevoked(ctx context.Context, family string) (bool, error)
+	// RevokeToken adds an access token jti to the denylist
+	RevokeToken(ctx context.Context, jti string, until time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeSubject invalidates every token of subject issued up to at
+	RevokeSubject(ctx context.Context, subject string, at time.Time, until time.Time) error
+	// SubjectRevokedAt returns the zero time when the subject was never revoked
+	SubjectRevokedAt(ctx context.Context, subject string) (time.Time, error)
+}
+
+// CacheTokenStore keeps token state in a cache.Store (memory or Redis)
+type CacheTokenStore struct {
+	store  cache.Store
+	Prefix string
+}
+
+func NewCacheTokenStore(store cache.Store) *CacheTokenStore {
+	return &CacheTokenStore{store: store, Prefix: "auth:"}
+}
+
+func (s *CacheTokenStore) SaveRefresh(ctx context.Context, tokenHash string, session RefreshSession) error {
+	return s.store.Set(ctx, s.Prefix+"refresh:"+tokenHash, session, time.Until(session.ExpiresAt))
+}
+
+func (s *CacheTokenStore) FindRefresh(ctx context.Context, tokenHash string) (*RefreshSession, error) {
+	var session RefreshSession
+	err := s.store.Get(ctx, s.Prefix+"refresh:"+tokenHash, &session)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *CacheTokenStore) MarkRefreshUsed(ctx context.Context, tokenHash string, until time.Time) (bool, error) {
+	return s.store.Add(ctx, s.Prefix+"used:"+tokenHash, true, time.Until(until))
+}
+
+func (s *CacheTokenStore) RevokeFamily(ctx context.Context, family string, until time.Time) error {
+	return s.store.Set(ctx, s.Prefix+"family:"+family, true, time.Until(until))
+}
+
+func (s *CacheTokenStore) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	return s.exists(ctx, s.Prefix+"family:"+family)
+}
+
+func (s *CacheTokenStore) RevokeToken(ctx context.Context, jti string, until time.Time) error {
+	return s.store.Set(ctx, s.Prefix+"denylist:"+jti, true, time.Until(until))
+}
+
+func (s *CacheTokenStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.exists(ctx, s.Prefix+"denylist:"+jti)
+}
+
+func (s *CacheTokenStore) RevokeSubject(ctx context.Context, subject string, at time.Time, until time.Time) error {
+	return s.store.Set(ctx, s.Prefix+"subject:"+subject, at, time.Until(until))
+}
+
+func (s *CacheTokenStore) SubjectRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	var at time.Time
+	err := s.store.Get(ctx, s.Prefix+"subject:"+subject, &at)
+	if errors.Is(err, cache.ErrNotFound) {
+		return time.Time{}, nil
+	}
+	return at, err
+}
+
+func (s *CacheTokenStore) exists(ctx context.Context, key string) (bool, error) {
+	var marker bool
+	err := s.store.Get(ctx, key, &marker)
+	if errors.Is(err, cache.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RevocationChecker reports whether verified access token claims were revoked
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims map[string]interface{}) (bool, error)
+}
+
+// TokenService issues short-lived access tokens with rotating refresh tokens.
+// Refresh tokens are opaque random strings; only their SHA-256 hash is stored.
+type TokenService struct {
+	Jwt        *JwtService
+	Store      TokenStore
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Auditor records refresh token reuse and logouts
+	Auditor *Auditor
+}
+
+// NewTokenService also makes jwtService reject revoked tokens, so AuthGuard
+// honours logouts once a TokenService exists
+func NewTokenService(jwtService *JwtService, store TokenStore) *TokenService {
+	service := &TokenService{
+		Jwt:        jwtService,
+		Store:      store,
+		AccessTTL:  DefaultAccessTokenTTL,
+		RefreshTTL: DefaultRefreshTokenTTL,
+	}
+	jwtService.Revocations = service
+	return service
+}
+
+// Issue starts a new session (token family) for subject, e.g. after login.
+// claims are copied into every access token of the session.
+func (s *TokenService) Issue(ctx context.Context, subject string, claims map[string]any) (*TokenPair, error) {
+	return s.issue(ctx, uuid.NewString(), subject, claims)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// rotated out; presenting it again revokes the whole family.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	tokenHash := hashToken(refreshToken)
+	session, err := s.Store.FindRefresh(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Marking the token used is the single atomic step: of concurrent refreshes with
+	// the same token only one wins, the others are treated as reuse
+	first, err := s.Store.MarkRefreshUsed(ctx, tokenHash, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		// The token leaked or was replayed: log out every holder of the family
+		if err := s.Store.RevokeFamily(ctx, session.Family, time.Now().Add(s.RefreshTTL)); err != nil {
+			return nil, err
+		}
+		s.Auditor.Log(ctx, AuditRecord{
+			Actor:     session.Subject,
+			ActorType: "user",
+			Action:    "auth.refresh_reused",
+			Resource:  "session:" + session.Family,
+			Outcome:   AuditDenied,
+		})
+		return nil, ErrRefreshTokenReused
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.Store.IsFamilyRevoked(ctx, session.Family)
+	if err != nil {
+		return nil, err
+	}
+	revokedAt, err := s.Store.SubjectRevokedAt(ctx, session.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if revoked || (!revokedAt.IsZero() && !session.IssuedAt.After(revokedAt)) {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issue(ctx, session.Family, session.Subject, session.Claims)
+}
+
+// Logout revokes the access token described by claims and its session
+func (s *TokenService) Logout(ctx context.Context, claims map[string]interface{}) error {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		until := time.Now().Add(s.AccessTTL)
+		if exp, ok := numericClaim(claims, "exp"); ok {
+			until = time.Unix(exp, 0)
+		}
+		if err := s.Store.RevokeToken(ctx, jti, until); err != nil {
+			return err
+		}
+	}
+	if family, ok := claims["sid"].(string); ok && family != "" {
+		return s.Store.RevokeFamily(ctx, family, time.Now().Add(s.RefreshTTL))
+	}
+	return nil
+}
+
+// LogoutAll revokes every access and refresh token issued to subject so far
+func (s *TokenService) LogoutAll(ctx context.Context, subject string) error {
+	now := time.Now()
+	return s.Store.RevokeSubject(ctx, subject, now, now.Add(s.RefreshTTL))
+}
+
+// IsRevoked checks the jti denylist, the session and logout-all of the subject
+func (s *TokenService) IsRevoked(ctx context.Context, claims map[string]interface{}) (bool, error) {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		if revoked, err := s.Store.IsTokenRevoked(ctx, jti); err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if family, ok := claims["sid"].(string); ok && family != "" {
+		if revoked, err := s.Store.IsFamilyRevoked(ctx, family); err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if subject, ok := claims["sub"].(string); ok && subject != "" {
+		revokedAt, err := s.Store.SubjectRevokedAt(ctx, subject)
+		if err != nil || revokedAt.IsZero() {
+			return false, err
+		}
+		return issuedAtMicros(claims) <= revokedAt.UnixMicro(), nil
+	}
+	return false, nil
+}
+
+func (s *TokenService) issue(ctx context.Context, family string, subject string, claims map[string]any) (*TokenPair, error) {
+	now := time.Now()
+
+	accessClaims := make(map[string]interface{}, len(claims)+5)
+	for k, v := range claims {
+		accessClaims[k] = v
+	}
+	accessClaims["sub"] = subject
+	accessClaims["jti"] = uuid.NewString()
+	accessClaims["sid"] = family
+	// Microsecond precision tells tokens of a re-login apart from those revoked
+	// by a LogoutAll in the same second
+	accessClaims["iat"] = float64(now.UnixMicro()) / 1e6
+	accessClaims["exp"] = now.Add(s.AccessTTL).Unix()
+
+	accessToken, err := s.Jwt.Sign(accessClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	session := RefreshSession{
+		Family:    family,
+		Subject:   subject,
+		Claims:    claims,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.RefreshTTL),
+	}
+	if err := s.Store.SaveRefresh(ctx, hashToken(refreshToken), session); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.AccessTTL.Seconds()),
+		RefreshExpiresIn: int64(s.RefreshTTL.Seconds()),
+	}, nil
+}
+
+// issuedAtMicros reads "iat", which issue writes with microsecond precision
+func issuedAtMicros(claims map[string]interface{}) int64 {
+	if iat, ok := claims["iat"].(float64); ok {
+		return int64(math.Round(iat * 1e6))
+	}
+	iat, _ := numericClaim(claims, "iat")
+	return iat * 1e6
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// numericClaim reads a NumericDate claim, which decodes as float64 from JSON
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshHandler exchanges {"refresh_token": "..."} for a new TokenPair
+func RefreshHandler(tokens *TokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req, err := core.Bind[refreshRequest](c)
+		if err != nil {
+			return err
+		}
+		pair, err := tokens.Refresh(c.UserContext(), req.RefreshToken)
+		if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+			return core.Unauthorized("Invalid refresh token").WithCause(err)
+		}
+		if err != nil {
+			return err
+		}
+		return core.Respond(c, core.HttpSuccessWithData("Token refreshed", core.HttpStatusOK, pair))
+	}
+}
+
+// LogoutHandler revokes the current access token and its session. Mount it behind AuthGuard.
+func LogoutHandler(tokens *TokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals(UserLocalsKey).(map[string]interface{})
+		if !ok {
+			return core.Unauthorized("Unauthorized")
+		}
+		if err := tokens.Logout(c.UserContext(), claims); err != nil {
+			return err
+		}
+		sid, _ := claims["sid"].(string)
+		tokens.Auditor.Record(c, "auth.logout", "session:"+sid, AuditSuccess, nil)
+		return core.NoContent(c)
+	}
+}
+
+// LogoutAllHandler revokes every session of the current user. Mount it behind AuthGuard.
+func LogoutAllHandler(tokens *TokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, _ := c.Locals(UserLocalsKey).(map[string]interface{})
+		subject, ok := claims["sub"].(string)
+		if !ok || subject == "" {
+			return core.Unauthorized("Unauthorized")
+		}
+		if err := tokens.LogoutAll(c.UserContext(), subject); err != nil {
+			return err
+		}
+		tokens.Auditor.Record(c, "auth.logout_all", "user:"+subject, AuditSuccess, nil)
+		return core.NoContent(c)
+	}
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/Alexigbokwe/goNextCore/core/config"
+)
+
+// Start listens according to the server configuration: a Unix socket when
+// SERVER_UNIX_SOCKET is set, TLS when a certificate is configured (or TLSConfig
+// was provided), plain TCP otherwise. Incomplete or conflicting settings, such as
+// TLS together with a Unix socket, are reported instead of being ignored.
+func (a *App) Start() error {
+	if a.Config == nil {
+		return errors.New("server: app has no configuration")
+	}
+	server := a.Config.Server
+	if err := validateServerConfig(server, a.TLSConfig != nil); err != nil {
+		return err
+	}
+
+	if server.UnixSocket != "" {
+		return a.ListenUnix(server.UnixSocket)
+	}
+
+	if a.TLSConfig != nil {
+		return a.ListenTLSConfig(server.Addr(), a.TLSConfig)
+	}
+
+	if server.TLSEnabled() {
+		tlsConfig, err := NewTLSConfig(server)
+		if err != nil {
+			return err
+		}
+		return a.ListenTLSConfig(server.Addr(), tlsConfig)
+	}
+
+	return a.Listen(server.Addr())
+}
+
+func validateServerConfig(server config.ServerConfig, hasTLSConfig bool) error {
+	if (server.TLSCertFile == "") != (server.TLSKeyFile == "") {
+		return errors.New("server: SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	if server.TLSClientCAFile != "" && !server.TLSEnabled() && !hasTLSConfig {
+		return errors.New("server: SERVER_TLS_CLIENT_CA_FILE requires a TLS certificate")
+	}
+	if server.UnixSocket != "" && (server.TLSEnabled() || hasTLSConfig) {
+		return errors.New("server: TLS is not supported on SERVER_UNIX_SOCKET; configure one or the other")
+	}
+	return nil
+}
+
+// ListenTLS listens on addr using the given certificate and key files
+func (a *App) ListenTLS(addr, certFile, keyFile string) error {
+	tlsConfig, err := NewTLSConfig(config.ServerConfig{
+		TLSCertFile: certFile,
+		TLSKeyFile:  keyFile,
+	})
+	if err != nil {
+		return err
+	}
+	return a.ListenTLSConfig(addr, tlsConfig)
+}
+
+// ListenMutualTLS listens on addr and requires clients to present a certificate signed by clientCAFile
+func (a *App) ListenMutualTLS(addr, certFile, keyFile, clientCAFile string) error {
+	tlsConfig, err := NewTLSConfig(config.ServerConfig{
+		TLSCertFile:     certFile,
+		TLSKeyFile:      keyFile,
+		TLSClientCAFile: clientCAFile,
+	})
+	if err != nil {
+		return err
+	}
+	return a.ListenTLSConfig(addr, tlsConfig)
+}
+
+// ListenTLSConfig listens on addr using an in-memory TLS configuration
+func (a *App) ListenTLSConfig(addr string, tlsConfig *tls.Config) error {
+	ln, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return a.Listener(ln)
+}
+
+// ListenUnix listens on a Unix domain socket, replacing a stale socket file if present
+func (a *App) ListenUnix(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return a.Listener(ln)
+}
+
+// Listener serves the app on a caller-provided listener
+func (a *App) Listener(ln net.Listener) error {
+	a.mountDefaultRoute()
+	return a.App.Listener(ln)
+}
+
+// NewTLSConfig builds a server TLS configuration from certificate files.
+// When a client CA is configured, client certificates are verified against it.
+func NewTLSConfig(server config.ServerConfig) (*tls.Config, error) {
+	if !server.TLSEnabled() {
+		return nil, errors.New("tls: certificate and key files are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(server.TLSCertFile, server.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: cannot load key pair: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	clientAuth, err := parseClientAuth(server.TLSClientAuth, server.TLSClientCAFile != "")
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientAuth = clientAuth
+
+	if server.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(server.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: cannot read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("tls: client CA file contains no certificates")
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func parseClientAuth(mode string, hasClientCA bool) (tls.ClientAuthType, error) {
+	switch mode {
+	case "":
+		if hasClientCA {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require-and-verify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("tls: unknown client auth mode %q", mode)
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status is an HTTP status code, e.g. Status(HttpStatusNotFound).Text() == "Not Found".
+// It is written to JSON as a number; use StatusText for fields carrying the name.
+type Status int
+
+// StatusText is a Status written to JSON as its reason phrase, e.g. "Not Found".
+// Unmarshalling accepts both numbers and names, as for Status.
+type StatusText Status
+
+// Code returns the status as a plain int
+func (s Status) Code() int {
+	return int(s)
+}
+
+// Text returns the reason phrase, e.g. "Not Found"
+func (s Status) Text() string {
+	return StatusTitle(int(s))
+}
+
+// String returns the code and reason phrase, e.g. "404 Not Found"
+func (s Status) String() string {
+	if text := s.Text(); text != "" {
+		return strconv.Itoa(int(s)) + " " + text
+	}
+	return strconv.Itoa(int(s))
+}
+
+func (s Status) IsInformational() bool {
+	return s >= 100 && s < 200
+}
+
+func (s Status) IsSuccess() bool {
+	return s >= 200 && s < 300
+}
+
+func (s Status) IsRedirect() bool {
+	return s >= 300 && s < 400
+}
+
+func (s Status) IsClientError() bool {
+	return s >= 400 && s < 500
+}
+
+func (s Status) IsServerError() bool {
+	return s >= 500 && s < 600
+}
+
+// IsError reports client and server errors
+func (s Status) IsError() bool {
+	return s.IsClientError() || s.IsServerError()
+}
+
+// IsRetryable reports statuses after which the same request may succeed later
+func (s Status) IsRetryable() bool {
+	switch s {
+	case HttpStatusRequestTimeout, HttpStatusTooEarly, HttpStatusTooManyRequests,
+		HttpStatusBadGateway, HttpStatusServiceUnavailable, HttpStatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// MarshalJSON writes the reason phrase, or the code for statuses without one
+func (s StatusText) MarshalJSON() ([]byte, error) {
+	if text := Status(s).Text(); text != "" {
+		return json.Marshal(text)
+	}
+	return Status(s).MarshalJSON()
+}
+
+func (s *StatusText) UnmarshalJSON(data []byte) error {
+	return (*Status)(s).UnmarshalJSON(data)
+}
+
+// UnmarshalJSON accepts a code (404) or a name ("Not Found", "not_found", "NotFound")
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var code int
+	if err := json.Unmarshal(data, &code); err == nil {
+		*s = Status(code)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("status must be a number or a name: %w", err)
+	}
+	status, ok := ParseStatus(name)
+	if !ok {
+		return fmt.Errorf("unknown HTTP status %q", name)
+	}
+	*s = status
+	return nil
+}
+
+// ParseStatus looks up a status by code or name, ignoring case, spaces and punctuation
+func ParseStatus(value string) (Status, bool) {
+	if code, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return Status(code), true
+	}
+
+	wanted := normalizeStatusName(value)
+	for code, title := range statusTitles {
+		if normalizeStatusName(title) == wanted {
+			return Status(code), true
+		}
+	}
+	return 0, false
+}
+
+func normalizeStatusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// Created responds 201 with data in the standard envelope
+func Created(c *fiber.Ctx, data any) error {
+	return Respond(c, HttpSuccessWithData(StatusTitle(HttpStatusCreated), HttpStatusCreated, data))
+}
+
+// CreatedAt responds 201 with data and a Location header pointing at the new resource
+func CreatedAt(c *fiber.Ctx, location string, data any) error {
+	c.Location(location)
+	return Created(c, data)
+}
+
+// Accepted responds 202 for work processed asynchronously. A non-empty location
+// (typically a status endpoint) is sent as the Location header.
+func Accepted(c *fiber.Ctx, location string) error {
+	if location != "" {
+		c.Location(location)
+	}
+	return Respond(c, HttpSuccess(StatusTitle(HttpStatusAccepted), HttpStatusAccepted))
+}
+
+// NoContent responds 204 with an empty body
+func NoContent(c *fiber.Ctx) error {
+	return c.SendStatus(HttpStatusNoContent)
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []security.AuditRecord
+}
+
+func (s *memoryAuditSink) Write(_ context.Context, record security.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memoryAuditSink) last(t *testing.T) security.AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.NotEmpty(t, s.records)
+	return s.records[len(s.records)-1]
+}
+
+func TestAuditDecorator(t *testing.T) {
+	sink := &memoryAuditSink{}
+	auditor := security.NewAuditor(sink)
+	jwtService := &security.JwtService{SecretKey: "test_secret"}
+	auth := security.Authenticate(security.NewJwtStrategy(jwtService))
+	auth.Auditor = auditor
+
+	app := core.NewApp()
+	app.Use(auditor.AuditMutations())
+	app.Delete("/orders/:id", auth.Use(), auditor.Audit("order.delete"), func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return core.NotFound("Order not found")
+		}
+		security.AuditDetails(c, "order:"+c.Params("id"), map[string]any{"reason": "duplicate"})
+		return core.NoContent(c)
+	})
+	app.Get("/orders", func(c *fiber.Ctx) error {
+		return c.SendString("[]")
+	})
+
+	token, err := jwtService.Sign(map[string]any{"sub": "user-1"})
+	require.NoError(t, err)
+	send := func(method string, path string, token string) {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+
+	send("DELETE", "/orders/42", token)
+	require.Len(t, sink.records, 2)
+	record := sink.records[0]
+	assert.Equal(t, "order.delete", record.Action)
+	assert.Equal(t, "order:42", record.Resource)
+	assert.Equal(t, security.AuditSuccess, record.Outcome)
+	assert.Equal(t, "user-1", record.Actor)
+	assert.Equal(t, "user", record.ActorType)
+	assert.NotEmpty(t, record.RequestID)
+	assert.NotEmpty(t, record.IP)
+	assert.NotEmpty(t, record.ID)
+	assert.Equal(t, "duplicate", record.Metadata["reason"])
+	// The app wide decorator records the mutation as well
+	assert.Equal(t, "DELETE /orders/:id", sink.records[1].Action)
+
+	send("DELETE", "/orders/missing", token)
+	assert.Equal(t, security.AuditFailure, sink.last(t).Outcome)
+	assert.Equal(t, 404, sink.last(t).Metadata["status"])
+
+	send("DELETE", "/orders/42", "forged.token.value")
+	failed := sink.records[len(sink.records)-2]
+	assert.Equal(t, "auth.failed", failed.Action)
+	assert.Equal(t, security.AuditDenied, failed.Outcome)
+	assert.Equal(t, security.AuditDenied, sink.last(t).Outcome)
+
+	count := len(sink.records)
+	send("GET", "/orders", "")
+	assert.Len(t, sink.records, count, "reads are not recorded")
+}
+
+func TestAuditRefreshTokenReuse(t *testing.T) {
+	sink := &memoryAuditSink{}
+	tokens := newTokenService()
+	tokens.Auditor = security.NewAuditor(sink)
+
+	ctx := context.Background()
+	login, err := tokens.Issue(ctx, "user-1", nil)
+	require.NoError(t, err)
+	_, err = tokens.Refresh(ctx, login.RefreshToken)
+	require.NoError(t, err)
+	_, err = tokens.Refresh(ctx, login.RefreshToken)
+	require.ErrorIs(t, err, security.ErrRefreshTokenReused)
+
+	record := sink.last(t)
+	assert.Equal(t, "auth.refresh_reused", record.Action)
+	assert.Equal(t, "user-1", record.Actor)
+	assert.Equal(t, security.AuditDenied, record.Outcome)
+}
+
+func TestFileAuditSinkHashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("audit-chain-key")
+	ctx := context.Background()
+
+	_, err := security.OpenFileAuditSink(path, nil)
+	assert.Error(t, err)
+
+	sink, err := security.OpenFileAuditSink(path, key)
+	require.NoError(t, err)
+	auditor := security.NewAuditor(sink)
+	auditor.Log(ctx, security.AuditRecord{Actor: "user-1", Action: "user.update", Outcome: security.AuditSuccess})
+	auditor.Log(ctx, security.AuditRecord{Actor: "user-1", Action: "user.delete", Outcome: security.AuditSuccess})
+	require.NoError(t, sink.Close())
+
+	// Reopening continues the chain
+	sink, err = security.OpenFileAuditSink(path, key)
+	require.NoError(t, err)
+	security.NewAuditor(sink).Log(ctx, security.AuditRecord{Actor: "user-2", Action: "role.grant", Outcome: security.AuditSuccess})
+	head := sink.Head()
+	require.NoError(t, sink.Close())
+
+	count, err := security.VerifyAuditFile(path, key, head)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// The chain cannot be recomputed without the key
+	_, err = security.VerifyAuditFile(path, []byte("other-key"), "")
+	assert.ErrorContains(t, err, "line 1")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	tampered := strings.Replace(string(data), "user.delete", "user.read", 1)
+	require.NoError(t, os.WriteFile(path, []byte(tampered), 0o600))
+
+	count, err = security.VerifyAuditFile(path, key, "")
+	assert.Error(t, err)
+	assert.Equal(t, 1, count)
+
+	lines := strings.SplitAfter(string(data), "\n")
+	require.NoError(t, os.WriteFile(path, []byte(lines[0]+lines[2]), 0o600))
+	_, err = security.VerifyAuditFile(path, key, "")
+	assert.ErrorContains(t, err, "line 2")
+
+	// Truncation leaves a valid chain that no longer reaches the anchored head
+	require.NoError(t, os.WriteFile(path, []byte(lines[0]+lines[1]), 0o600))
+	count, err = security.VerifyAuditFile(path, key, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	_, err = security.VerifyAuditFile(path, key, head)
+	assert.Error(t, err)
+}
+
+func TestFileAuditSinkDiscardsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("audit-chain-key")
+	ctx := context.Background()
+
+	sink, err := security.OpenFileAuditSink(path, key)
+	require.NoError(t, err)
+	security.NewAuditor(sink).Log(ctx, security.AuditRecord{Actor: "user-1", Action: "user.update", Outcome: security.AuditSuccess})
+	require.NoError(t, sink.Close())
+
+	// A crash mid-write leaves a line without its newline
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"prev_hash":"ab`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	_, err = security.VerifyAuditFile(path, key, "")
+	assert.ErrorContains(t, err, "line 2")
+
+	sink, err = security.OpenFileAuditSink(path, key)
+	require.NoError(t, err)
+	security.NewAuditor(sink).Log(ctx, security.AuditRecord{Actor: "user-1", Action: "user.delete", Outcome: security.AuditSuccess})
+	require.NoError(t, sink.Close())
+
+	count, err := security.VerifyAuditFile(path, key, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
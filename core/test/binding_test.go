@@ -0,0 +1,75 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CreateOrderDto struct {
+	StoreID  int    `params:"storeId" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Quantity int    `json:"quantity" default:"1" validate:"min=1"`
+	Coupon   string `query:"coupon"`
+	Tenant   string `header:"X-Tenant" validate:"required"`
+}
+
+func TestValidateBody(t *testing.T) {
+	app := core.NewApp()
+	app.Post("/stores/:storeId/orders", append(core.Combine(core.ValidateBody[CreateOrderDto]()), func(c *fiber.Ctx) error {
+		return c.JSON(core.Body[CreateOrderDto](c))
+	})...)
+
+	req := httptest.NewRequest("POST", "/stores/42/orders?coupon=FALL", strings.NewReader(`{"email":"ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var dto CreateOrderDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dto))
+	assert.Equal(t, CreateOrderDto{StoreID: 42, Email: "ada@example.com", Quantity: 1, Coupon: "FALL", Tenant: "acme"}, dto)
+
+	req = httptest.NewRequest("POST", "/stores/42/orders", strings.NewReader(`{"email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	var body struct {
+		Message string                  `json:"message"`
+		Data    []*core.ValidationError `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "Validation failed", body.Message)
+	assert.Len(t, body.Data, 2)
+}
+
+func TestValidateBodyGroupedErrors(t *testing.T) {
+	app := core.NewAppWithConfig(&config.Config{App: config.AppConfig{GroupValidationErrors: true}})
+	app.Post("/stores/:storeId/orders", append(core.Combine(core.ValidateBody[CreateOrderDto]()), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})...)
+
+	req := httptest.NewRequest("POST", "/stores/42/orders", strings.NewReader(`{"email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	var body struct {
+		Data map[string][]string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body.Data, "email")
+	assert.Contains(t, body.Data, "Tenant")
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/Alexigbokwe/goNextCore/core/storage"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncrypterKeyRotation(t *testing.T) {
+	oldKey, err := security.GenerateEncryptionKey()
+	require.NoError(t, err)
+	newKey, err := security.GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	encrypter, err := security.NewEncrypter("2024", oldKey)
+	require.NoError(t, err)
+	sealed, err := encrypter.Encrypt([]byte("4111 1111 1111 1111"), []byte("user:1"))
+	require.NoError(t, err)
+
+	kid, _, err := security.KeyIDOf(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "2024", kid)
+
+	require.NoError(t, encrypter.Rotate("2025", newKey))
+	assert.True(t, encrypter.NeedsReencrypt(sealed))
+
+	plaintext, err := encrypter.Decrypt(sealed, []byte("user:1"))
+	require.NoError(t, err)
+	assert.Equal(t, "4111 1111 1111 1111", string(plaintext))
+
+	// Associated data binds the ciphertext to its row
+	_, err = encrypter.Decrypt(sealed, []byte("user:2"))
+	assert.ErrorIs(t, err, security.ErrInvalidCiphertext)
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = encrypter.Decrypt(tampered)
+	assert.ErrorIs(t, err, security.ErrInvalidCiphertext)
+
+	encrypter.RemoveKey("2024")
+	_, err = encrypter.Decrypt(sealed, []byte("user:1"))
+	assert.ErrorIs(t, err, security.ErrUnknownKeyID)
+}
+
+func TestEncryptedStringColumn(t *testing.T) {
+	key, err := security.GenerateEncryptionKey()
+	require.NoError(t, err)
+	security.ColumnEncrypter, err = security.NewEncrypter("k1", key)
+	require.NoError(t, err)
+	defer func() { security.ColumnEncrypter = nil }()
+
+	value, err := security.EncryptedString("123-45-6789").Value()
+	require.NoError(t, err)
+	stored, ok := value.(string)
+	require.True(t, ok)
+	assert.NotContains(t, stored, "123-45-6789")
+
+	var scanned security.EncryptedString
+	require.NoError(t, scanned.Scan([]byte(stored)))
+	assert.Equal(t, security.EncryptedString("123-45-6789"), scanned)
+	assert.Equal(t, "[encrypted]", scanned.String())
+
+	require.NoError(t, scanned.Scan(nil))
+	assert.Empty(t, string(scanned))
+}
+
+func TestSignerPayloadsAndURLs(t *testing.T) {
+	signer := security.NewSigner([]byte("current-key"))
+
+	token, err := signer.Sign([]byte("user:42"), time.Hour)
+	require.NoError(t, err)
+	payload, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user:42", string(payload))
+
+	_, err = signer.Verify(strings.Replace(token, "dXNlcjo0Mg", "dXNlcjo0Mw", 1))
+	assert.ErrorIs(t, err, security.ErrInvalidSignature)
+
+	permanentToken, err := signer.Sign([]byte("user:42"), security.NoExpiry)
+	require.NoError(t, err)
+	_, err = signer.Verify(permanentToken)
+	assert.NoError(t, err)
+
+	_, err = signer.Sign([]byte("user:42"), -time.Hour)
+	assert.ErrorIs(t, err, security.ErrInvalidTTL)
+	_, err = signer.SignURL("/files/report.pdf", -time.Second)
+	assert.ErrorIs(t, err, security.ErrInvalidTTL)
+
+	// Tokens of a previous key stay valid during rotation
+	rotated := security.NewSigner([]byte("next-key"), []byte("current-key"))
+	_, err = rotated.Verify(token)
+	assert.NoError(t, err)
+
+	link, err := signer.SignURL("https://example.com/verify-email?user=42", time.Hour)
+	require.NoError(t, err)
+	assert.NoError(t, signer.VerifyURL(link))
+	assert.ErrorIs(t, signer.VerifyURL(strings.Replace(link, "user=42", "user=43", 1)), security.ErrInvalidSignature)
+
+	disk := storage.NewLocalDisk(t.TempDir(), "/files")
+	temporary, err := signer.TemporaryURL(disk, "report.pdf", time.Minute)
+	require.NoError(t, err)
+
+	app := core.NewApp()
+	app.Get("/files/:name", (&security.SignedURLGuard{Signer: signer}).Use(), func(c *fiber.Ctx) error {
+		return c.SendString(c.Params("name"))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", temporary, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/files/report.pdf", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// A forged expires parameter is replaced when signing
+	permanent, err := signer.SignURL("/files/report.pdf?expires=1", security.NoExpiry)
+	require.NoError(t, err)
+	assert.NotContains(t, permanent, "expires=")
+	resp, err = app.Test(httptest.NewRequest("GET", permanent, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errOrderMissing = errors.New("order missing")
+
+func decodeResponse(t *testing.T, app *core.App, path string) (int, core.HttpResponseType[interface{}]) {
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body core.HttpResponseType[interface{}]
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func TestHttpExceptionHandling(t *testing.T) {
+	app := core.NewAppWithConfig(&config.Config{App: config.AppConfig{Env: "production"}})
+	app.UseExceptionFilters(core.CatchIs(errOrderMissing, core.HttpStatusNotFound, "Order not found"))
+
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return core.Conflict("Email already taken").WithData(fiber.Map{"field": "email"})
+	})
+	app.Get("/fiber", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	})
+	app.Get("/filtered", func(c *fiber.Ctx) error {
+		return errOrderMissing
+	})
+	app.Get("/internal", func(c *fiber.Ctx) error {
+		return errors.New("pq: connection refused")
+	})
+
+	status, body := decodeResponse(t, app, "/conflict")
+	assert.Equal(t, 409, status)
+	assert.Equal(t, "Email already taken", body.Message)
+	assert.Equal(t, map[string]interface{}{"field": "email"}, body.Data)
+	assert.False(t, body.Status)
+
+	status, _ = decodeResponse(t, app, "/fiber")
+	assert.Equal(t, 418, status)
+
+	status, body = decodeResponse(t, app, "/filtered")
+	assert.Equal(t, 404, status)
+	assert.Equal(t, "Order not found", body.Message)
+
+	status, body = decodeResponse(t, app, "/internal")
+	assert.Equal(t, 500, status)
+	assert.Equal(t, "Internal Server Error", body.Message)
+}
+
+type recordingReporter struct {
+	recovered []interface{}
+}
+
+func (r *recordingReporter) ReportPanic(c *fiber.Ctx, recovered interface{}, stack []byte) {
+	r.recovered = append(r.recovered, recovered)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	app := core.NewApp()
+	reporter := &recordingReporter{}
+	app.SetErrorReporter(reporter)
+
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	status, body := decodeResponse(t, app, "/panic")
+	assert.Equal(t, 500, status)
+	assert.Equal(t, "Internal Server Error", body.Message)
+	assert.Equal(t, []interface{}{"boom"}, reporter.recovered)
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHardenHeadersAndCors(t *testing.T) {
+	app := core.NewAppWithConfig(&config.Config{Cors: config.CorsConfig{
+		AllowOrigins:     "https://app.example.com",
+		AllowCredentials: true,
+	}})
+	security.Harden(app, security.HardenOptions{})
+	app.Get("/page", func(c *fiber.Ctx) error {
+		return c.SendString(security.CSPNonce(c))
+	})
+
+	req := httptest.NewRequest("GET", "/page", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	nonce := string(body)
+	require.NotEmpty(t, nonce)
+
+	assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "'nonce-"+nonce+"'")
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", resp.Header.Get("Referrer-Policy"))
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	// Plain HTTP never gets HSTS
+	assert.Empty(t, resp.Header.Get("Strict-Transport-Security"))
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+
+	req = httptest.NewRequest("GET", "/page", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestDoubleSubmitCSRF(t *testing.T) {
+	session := func(c *fiber.Ctx) string { return c.Cookies("session") }
+	csrf := security.NewDoubleSubmitCSRF([]byte("csrf-secret"), session)
+	csrf.Secure = false
+
+	app := core.NewApp()
+	security.Harden(app, security.HardenOptions{CSRF: csrf})
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendString(security.CSRFToken(c))
+	})
+	app.Post("/transfer", func(c *fiber.Ctx) error {
+		return c.SendString("done")
+	})
+
+	issue := func(sessionID string) *http.Cookie {
+		req := httptest.NewRequest("GET", "/form", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: sessionID})
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		for _, c := range resp.Cookies() {
+			if c.Name == "csrf_token" {
+				return c
+			}
+		}
+		return nil
+	}
+	cookie := issue("victim")
+	require.NotNil(t, cookie)
+
+	post := func(token string, csrfCookie *http.Cookie) int {
+		req := httptest.NewRequest("POST", "/transfer", strings.NewReader("_csrf="+token))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "victim"})
+		if csrfCookie != nil {
+			req.AddCookie(csrfCookie)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, post(cookie.Value, cookie))
+	assert.Equal(t, 403, post("forged", cookie))
+	assert.Equal(t, 403, post(cookie.Value, nil))
+
+	// A cookie planted by an attacker, unsigned or signed for their own session, is rejected
+	assert.Equal(t, 403, post("planted", &http.Cookie{Name: "csrf_token", Value: "planted"}))
+	attacker := issue("attacker")
+	require.NotNil(t, attacker)
+	assert.Equal(t, 403, post(attacker.Value, attacker))
+}
+
+func TestSynchronizerCSRF(t *testing.T) {
+	session := func(c *fiber.Ctx) string { return c.Cookies("session") }
+	csrf := security.NewSynchronizerCSRF(cache.NewMemoryStore(), session)
+
+	app := core.NewApp()
+	app.Use(csrf.Use())
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendString(security.CSRFToken(c))
+	})
+	app.Post("/transfer", func(c *fiber.Ctx) error {
+		return c.SendString("done")
+	})
+
+	request := func(method string, path string, sessionID string, token string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "session", Value: sessionID})
+		}
+		if token != "" {
+			req.Header.Set("X-CSRF-Token", token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := request("GET", "/form", "alice", "")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	token := string(body)
+	require.NotEmpty(t, token)
+
+	assert.Equal(t, 200, request("POST", "/transfer", "alice", token).StatusCode)
+	assert.Equal(t, 403, request("POST", "/transfer", "alice", "").StatusCode)
+	// Tokens are bound to their session
+	assert.Equal(t, 403, request("POST", "/transfer", "mallory", token).StatusCode)
+	// Without a session the request carries no cookie credentials to abuse
+	assert.Equal(t, 200, request("POST", "/transfer", "", "").StatusCode)
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgon2idHashing(t *testing.T) {
+	argon := security.NewArgon2idService()
+	hash, err := argon.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+	assert.True(t, argon.Compare(hash, "correct horse battery staple"))
+	assert.False(t, argon.Compare(hash, "Correct horse battery staple"))
+	assert.False(t, argon.NeedsRehash(hash))
+
+	stronger := security.NewArgon2idService()
+	stronger.Iterations = 3
+	assert.True(t, stronger.NeedsRehash(hash))
+	assert.True(t, stronger.Compare(hash, "correct horse battery staple"))
+}
+
+func TestMultiHashServiceUpgradesLegacyHashes(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("hunter22"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	hasher := security.NewHashService()
+	assert.True(t, hasher.Compare(string(legacy), "hunter22"))
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+
+	upgraded, err := hasher.Hash("hunter22")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upgraded, "$argon2id$"))
+	assert.False(t, hasher.NeedsRehash(upgraded))
+	assert.False(t, hasher.Compare("$unknown$hash", "hunter22"))
+}
+
+func TestBcryptPreHashing(t *testing.T) {
+	service := security.NewBcryptService()
+	service.Cost = bcrypt.MinCost
+
+	long := strings.Repeat("a", 72)
+	hash, err := service.Hash(long + "-suffix-one")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$bcrypt-hmac-sha256$"))
+	assert.True(t, service.Compare(hash, long+"-suffix-one"))
+	assert.False(t, service.Compare(hash, long+"-suffix-two"))
+	assert.False(t, service.NeedsRehash(hash))
+
+	service.Cost = bcrypt.MinCost + 1
+	assert.True(t, service.NeedsRehash(hash))
+}
+
+func TestBcryptPreHashIsKeyed(t *testing.T) {
+	service := security.NewBcryptService()
+	service.Cost = bcrypt.MinCost
+
+	// A bcrypt hash of the plain SHA-256 digest (as in leaked digest corpora) does not verify
+	digest := sha256.Sum256([]byte("hunter22"))
+	shucked, err := bcrypt.GenerateFromPassword([]byte(base64.StdEncoding.EncodeToString(digest[:])), bcrypt.MinCost)
+	require.NoError(t, err)
+	assert.False(t, service.Compare("$bcrypt-hmac-sha256$"+string(shucked), "hunter22"))
+
+	hash, err := service.Hash("hunter22")
+	require.NoError(t, err)
+	peppered := security.NewBcryptService()
+	peppered.Cost = bcrypt.MinCost
+	peppered.Pepper = []byte("server-side-secret")
+	assert.False(t, peppered.Compare(hash, "hunter22"))
+}
+
+func TestArgon2idRejectsCorruptedParameters(t *testing.T) {
+	argon := security.NewArgon2idService()
+	hash, err := argon.Hash("hunter22")
+	require.NoError(t, err)
+	parts := strings.Split(hash, "$")
+
+	for _, params := range []string{
+		"m=19456,t=2,p=0",
+		"m=19456,t=0,p=1",
+		"m=4,t=2,p=1",
+		"m=4294967295,t=2,p=1",
+		"m=19456,t=100000,p=1",
+	} {
+		corrupted := strings.Join([]string{"", parts[1], parts[2], params, parts[4], parts[5]}, "$")
+		assert.NotPanics(t, func() {
+			assert.False(t, argon.Compare(corrupted, "hunter22"), params)
+		})
+		assert.True(t, argon.NeedsRehash(corrupted), params)
+	}
+
+	short := strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "AAA"}, "$")
+	assert.False(t, argon.Compare(short, "hunter22"))
+}
@@ -0,0 +1,213 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsymmetricJwtRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	first, err := security.NewSigningKey("2025-01", rsaKey)
+	require.NoError(t, err)
+	service := security.NewJwtServiceWithKeys(security.NewKeySet(first))
+
+	oldToken, err := service.Sign(map[string]interface{}{"sub": "42"})
+	require.NoError(t, err)
+
+	second, err := security.NewSigningKey("", ecKey)
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", second.Method.Alg())
+	require.NoError(t, service.Keys.Rotate(second))
+
+	newToken, err := service.Sign(map[string]interface{}{"sub": "42"})
+	require.NoError(t, err)
+
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := service.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, "42", claims["sub"])
+	}
+
+	service.Keys.Remove("2025-01")
+	_, err = service.Verify(oldToken)
+	assert.Error(t, err)
+
+	// HMAC tokens are rejected once asymmetric keys are configured
+	hmacToken, err := (&security.JwtService{SecretKey: "test_secret"}).Sign(map[string]interface{}{"sub": "42"})
+	require.NoError(t, err)
+	_, err = service.Verify(hmacToken)
+	assert.Error(t, err)
+
+	third, err := security.NewSigningKey("ed", edKey)
+	require.NoError(t, err)
+	require.NoError(t, service.Keys.Rotate(third))
+	edToken, err := service.Sign(map[string]interface{}{"sub": "7"})
+	require.NoError(t, err)
+	claims, err := service.Verify(edToken)
+	require.NoError(t, err)
+	assert.Equal(t, "7", claims["sub"])
+}
+
+func TestLoadSigningKeyFromPEM(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	key, err := security.LoadSigningKeyFile("", path)
+	require.NoError(t, err)
+	assert.True(t, key.CanSign())
+
+	thumbprint, err := security.Thumbprint(&ecKey.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, thumbprint, key.ID)
+}
+
+func TestJwksEndpointAndRemoteVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaSigning, err := security.NewSigningKey("rsa-1", rsaKey)
+	require.NoError(t, err)
+	edSigning, err := security.NewSigningKey("ed-1", edKey)
+	require.NoError(t, err)
+	keys := security.NewKeySet(rsaSigning, edSigning)
+	issuer := security.NewJwtServiceWithKeys(keys)
+
+	app := core.NewApp()
+	app.Get("/.well-known/jwks.json", security.JWKSHandler(keys))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	require.NoError(t, err)
+	var document security.JWKS
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&document))
+	require.Len(t, document.Keys, 2)
+	assert.Equal(t, "OKP", document.Keys[0].Kty)
+	assert.Equal(t, "RS256", document.Keys[1].Alg)
+	assert.NotEmpty(t, document.Keys[1].N)
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(document)
+	}))
+	defer server.Close()
+
+	verifier := &security.JwtService{Resolver: security.NewRemoteKeySet(server.URL)}
+
+	token, err := issuer.Sign(map[string]interface{}{"sub": "99"})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		claims, err := verifier.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, "99", claims["sub"])
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Unknown kids do not refetch the document within MinRefreshInterval
+	unknown, err := security.NewSigningKey("rsa-2", rsaKey)
+	require.NoError(t, err)
+	foreign, err := security.NewJwtServiceWithKeys(security.NewKeySet(unknown)).Sign(map[string]interface{}{"sub": "1"})
+	require.NoError(t, err)
+	_, err = verifier.Verify(foreign)
+	assert.ErrorIs(t, err, security.ErrKeyNotFound)
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestRemoteKeySetConcurrentRefresh(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signing, err := security.NewSigningKey("rsa-1", rsaKey)
+	require.NoError(t, err)
+	document, err := security.NewKeySet(signing).JWKS()
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	var blocking atomic.Bool
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if blocking.Load() {
+			started <- struct{}{}
+			<-release
+		}
+		json.NewEncoder(w).Encode(document)
+	}))
+	defer server.Close()
+
+	// Concurrent lookups on a cold cache share one fetch
+	remote := security.NewRemoteKeySet(server.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := remote.ResolveKey(context.Background(), "rsa-1")
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Cached keys resolve while a refresh for an unknown kid is in flight
+	remote = security.NewRemoteKeySet(server.URL)
+	remote.MinRefreshInterval = 0
+	_, err = remote.ResolveKey(context.Background(), "rsa-1")
+	require.NoError(t, err)
+
+	blocking.Store(true)
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := remote.ResolveKey(context.Background(), "rsa-2")
+		unknown <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	key, err := remote.ResolveKey(ctx, "rsa-1")
+	require.NoError(t, err)
+	assert.Equal(t, "rsa-1", key.ID)
+
+	close(release)
+	assert.ErrorIs(t, <-unknown, security.ErrKeyNotFound)
+}
+
+func TestSignRequiresKeysWithResolver(t *testing.T) {
+	service := &security.JwtService{SecretKey: "secret", Resolver: security.NewRemoteKeySet("http://127.0.0.1:0")}
+	_, err := service.Sign(map[string]interface{}{"sub": "1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/Alexigbokwe/goNextCore/core/security/mfa"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 with 8 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	totp := mfa.New("GoNext", nil)
+	totp.Digits = 8
+
+	for at, expected := range map[int64]string{
+		59:         "94287082",
+		1111111109: "07081804",
+		2000000000: "69279037",
+	} {
+		code, err := totp.Code(secret, time.Unix(at, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+
+	_, err := totp.Code("not base32!", time.Now())
+	assert.ErrorIs(t, err, mfa.ErrInvalidSecret)
+}
+
+func TestTOTPVerifyAndReplay(t *testing.T) {
+	ctx := context.Background()
+	totp := mfa.New("GoNext", cache.NewMemoryStore())
+
+	secret, err := mfa.GenerateSecret()
+	require.NoError(t, err)
+
+	uri, err := url.Parse(totp.ProvisioningURI(secret, "jane@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/GoNext:jane@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "GoNext", uri.Query().Get("issuer"))
+
+	// A code of the previous period is accepted for clock drift
+	previous, err := totp.Code(secret, time.Now().Add(-totp.Period))
+	require.NoError(t, err)
+	ok, err := totp.Verify(ctx, "jane", secret, previous)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	current, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	ok, err = totp.Verify(ctx, "jane", secret, current)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Accepted codes cannot be replayed
+	ok, err = totp.Verify(ctx, "jane", secret, current)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = totp.Verify(ctx, "jane", secret, previous)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Concurrent submissions of the same code succeed once
+	next, err := totp.Code(secret, time.Now().Add(totp.Period))
+	require.NoError(t, err)
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := totp.Verify(ctx, "jane", secret, next); err == nil && ok {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted.Load())
+
+	stale, err := totp.Code(secret, time.Now().Add(-5*totp.Period))
+	require.NoError(t, err)
+	ok, err = totp.Verify(ctx, "john", secret, stale)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// unavailableStore fails every once-only marker
+type unavailableStore struct{ cache.Store }
+
+func (unavailableStore) Add(context.Context, string, interface{}, time.Duration) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestTOTPRejectsWhenReplayStoreFails(t *testing.T) {
+	totp := mfa.New("GoNext", unavailableStore{cache.NewMemoryStore()})
+	secret, err := mfa.GenerateSecret()
+	require.NoError(t, err)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	ok, err := totp.Verify(context.Background(), "jane", secret, code)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	hasher := &security.BcryptService{Cost: bcrypt.MinCost}
+
+	codes, err := mfa.GenerateRecoveryCodes(8)
+	require.NoError(t, err)
+	require.Len(t, codes, 8)
+	assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, codes[0])
+
+	hashes, err := mfa.HashRecoveryCodes(hasher, codes)
+	require.NoError(t, err)
+
+	remaining, ok := mfa.RedeemRecoveryCode(hasher, hashes, " "+codes[3][:5]+codes[3][6:]+" ")
+	assert.True(t, ok)
+	assert.Len(t, remaining, 7)
+
+	// Codes are single use once the remaining hashes are persisted
+	_, ok = mfa.RedeemRecoveryCode(hasher, remaining, codes[3])
+	assert.False(t, ok)
+	_, ok = mfa.RedeemRecoveryCode(hasher, remaining, "")
+	assert.False(t, ok)
+
+	// Concurrent logins holding the same stored hashes redeem a code only once
+	store := cache.NewMemoryStore()
+	var wg sync.WaitGroup
+	var redeemed atomic.Int32
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := mfa.RedeemRecoveryCodeOnce(context.Background(), store, hasher, hashes, codes[5])
+			assert.NoError(t, err)
+			if ok {
+				redeemed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), redeemed.Load())
+}
+
+func TestMfaGuard(t *testing.T) {
+	jwtService := &security.JwtService{SecretKey: "test_secret"}
+	authenticated := security.Authenticate(security.NewJwtStrategy(jwtService))
+	guard := &mfa.MfaGuard{MaxAge: time.Hour}
+
+	app := core.NewApp()
+	app.Get("/billing", authenticated.Use(), guard.Use(), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	get := func(claims map[string]any) int {
+		token, err := jwtService.Sign(claims)
+		require.NoError(t, err)
+		req := httptest.NewRequest("GET", "/billing", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	password := map[string]any{"sub": "user-1", "amr": []string{"pwd"}}
+	assert.Equal(t, 403, get(password))
+
+	verified := mfa.WithSecondFactor(password, mfa.MethodOTP)
+	assert.Equal(t, []string{"pwd"}, password["amr"], "the original claims are left untouched")
+	assert.Equal(t, []string{"pwd", "otp", "mfa"}, verified["amr"])
+	assert.Equal(t, 200, get(verified))
+
+	verified[mfa.VerifiedAtClaim] = time.Now().Add(-2 * time.Hour).Unix()
+	assert.Equal(t, 403, get(verified))
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Alexigbokwe/goNextCore/core/database/migrate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationLoading(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20260102000000_add_orders.up.sql":   {Data: []byte("CREATE TABLE orders (id BIGINT);")},
+		"20260102000000_add_orders.down.sql": {Data: []byte("DROP TABLE orders;")},
+		"20260101000000_add_users.up.sql":    {Data: []byte("CREATE TABLE users (id BIGINT);")},
+		"README.md":                          {Data: []byte("ignored")},
+	}
+
+	migrations, err := migrate.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(20260101000000), migrations[0].Version)
+	assert.Equal(t, "add_users", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE orders;", migrations[1].Down)
+
+	_, err = migrate.Load(fstest.MapFS{
+		"1_missing_up.down.sql": {Data: []byte("DROP TABLE x;")},
+	})
+	assert.Error(t, err)
+}
+
+func TestMigrationCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	files, err := migrate.Create(dir, "Add Invoices Table")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Regexp(t, `\d{14}_add_invoices_table\.up\.sql$`, files[0])
+
+	migrations, err := migrate.Load(os.DirFS(dir))
+	require.NoError(t, err)
+	assert.Equal(t, "add_invoices_table", migrations[0].Name)
+}
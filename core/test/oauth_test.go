@@ -0,0 +1,259 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/Alexigbokwe/goNextCore/core/security/oauth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOIDCProvider issues codes for a single user, checking PKCE on exchange
+type fakeOIDCProvider struct {
+	*httptest.Server
+	t      *testing.T
+	signer *security.JwtService
+	keys   *security.KeySet
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key, err := security.NewSigningKey("fake-1", private)
+	require.NoError(t, err)
+	keys := security.NewKeySet(key)
+
+	p := &fakeOIDCProvider{t: t, keys: keys, signer: security.NewJwtServiceWithKeys(keys), codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"userinfo_endpoint":      p.URL + "/userinfo",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwks, err := p.keys.JWKS()
+		require.NoError(t, err)
+		writeJSON(w, jwks)
+	})
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]any{"sub": "google-123", "email": "jane@example.com", "email_verified": true, "name": "Jane"})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize simulates the user approving the login at the authorization URL
+func (p *fakeOIDCProvider) authorize(authURL string) (code string, state string) {
+	u, err := url.Parse(authURL)
+	require.NoError(p.t, err)
+	query := u.Query()
+	require.Equal(p.t, "S256", query.Get("code_challenge_method"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code = "code-" + query.Get("state")[:8]
+	p.codes[code] = query
+	return code, query.Get("state")
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(p.t, r.ParseForm())
+	p.mu.Lock()
+	request, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	clientID, secret, _ := r.BasicAuth()
+	if !ok || clientID != "app" || secret != "app-secret" ||
+		oauth.CodeChallenge(r.PostForm.Get("code_verifier")) != request.Get("code_challenge") {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.signer.Sign(map[string]any{
+		"iss":   p.URL,
+		"aud":   "app",
+		"sub":   "google-123",
+		"nonce": request.Get("nonce"),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	})
+	require.NoError(p.t, err)
+	writeJSON(w, map[string]any{"access_token": "provider-access-token", "token_type": "Bearer", "id_token": idToken})
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestOAuthLoginWithOIDCProvider(t *testing.T) {
+	fake := newFakeOIDCProvider(t)
+	provider := &oauth.Provider{Name: "google", ClientID: "app", ClientSecret: "app-secret", RedirectURL: "http://localhost/auth/google/callback"}
+	require.NoError(t, provider.Discover(context.Background(), fake.URL))
+	assert.Equal(t, fake.URL+"/token", provider.TokenURL)
+
+	var resolved *oauth.Identity
+	resolveUser := func(ctx context.Context, identity *oauth.Identity) (string, map[string]any, error) {
+		resolved = identity
+		return "user-7", map[string]any{"roles": []string{"member"}}, nil
+	}
+	tokens := newTokenService()
+	_, err := oauth.NewLogin(cache.NewMemoryStore(), resolveUser, nil, provider)
+	assert.Error(t, err)
+	login, err := oauth.NewLogin(cache.NewMemoryStore(), resolveUser, tokens, provider)
+	require.NoError(t, err)
+	login.SecureCookie = false
+
+	app := core.NewApp()
+	app.Get("/auth/:provider", login.Redirect())
+	app.Get("/auth/:provider/callback", login.Callback())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/auth/google", nil))
+	require.NoError(t, err)
+	require.Equal(t, 302, resp.StatusCode)
+	stateCookie := resp.Cookies()[0]
+	code, state := fake.authorize(resp.Header.Get("Location"))
+	assert.Equal(t, stateCookie.Value, state)
+
+	callback := func(code string, state string, cookie *http.Cookie) *http.Response {
+		req := httptest.NewRequest("GET", "/auth/google/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Provider errors are not reflected into the response
+	resp, err = app.Test(httptest.NewRequest("GET", "/auth/google/callback?error=%3Cscript%3Ealert(1)%3C%2Fscript%3E", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	errorBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(errorBody), "script")
+
+	// The state must come back in the browser that started the login
+	assert.Equal(t, 401, callback(code, state, nil).StatusCode)
+
+	resp = callback(code, state, stateCookie)
+	require.Equal(t, 200, resp.StatusCode)
+	var body core.HttpResponseType[security.TokenPair]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	claims, err := tokens.Jwt.Verify(body.Data.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-7", claims["sub"])
+	assert.NotEmpty(t, body.Data.RefreshToken)
+
+	require.NotNil(t, resolved)
+	assert.Equal(t, "google", resolved.Provider)
+	assert.Equal(t, "google-123", resolved.Subject)
+	assert.Equal(t, "jane@example.com", resolved.Email)
+	assert.True(t, resolved.EmailVerified)
+
+	// States are single use
+	assert.Equal(t, 401, callback(code, state, stateCookie).StatusCode)
+}
+
+// lateForgetStore never forgets, as when a second callback reads the state before
+// the first one deletes it
+type lateForgetStore struct {
+	cache.Store
+}
+
+func (s lateForgetStore) Forget(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestOAuthStateClaimedOnce(t *testing.T) {
+	fake := newFakeOIDCProvider(t)
+	provider := &oauth.Provider{Name: "google", ClientID: "app", ClientSecret: "app-secret", RedirectURL: "http://localhost/auth/google/callback"}
+	require.NoError(t, provider.Discover(context.Background(), fake.URL))
+	resolveUser := func(ctx context.Context, identity *oauth.Identity) (string, map[string]any, error) {
+		return "user-7", nil, nil
+	}
+	login, err := oauth.NewLogin(lateForgetStore{cache.NewMemoryStore()}, resolveUser, newTokenService(), provider)
+	require.NoError(t, err)
+	login.SecureCookie = false
+
+	app := core.NewApp()
+	app.Get("/auth/:provider", login.Redirect())
+	app.Get("/auth/:provider/callback", login.Callback())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/auth/google", nil))
+	require.NoError(t, err)
+	stateCookie := resp.Cookies()[0]
+	location := resp.Header.Get("Location")
+
+	callback := func() int {
+		// The provider accepts the code each time, so only the state check can stop the replay
+		code, state := fake.authorize(location)
+		req := httptest.NewRequest("GET", "/auth/google/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), nil)
+		req.AddCookie(stateCookie)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, 200, callback())
+	assert.Equal(t, 401, callback())
+}
+
+func TestOAuthRejectsForeignIDTokens(t *testing.T) {
+	fake := newFakeOIDCProvider(t)
+	provider := &oauth.Provider{Name: "google", ClientID: "app", ClientSecret: "app-secret"}
+	require.NoError(t, provider.Discover(context.Background(), fake.URL))
+
+	sign := func(claims map[string]any) string {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token, err := fake.signer.Sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+
+	ctx := context.Background()
+	_, err := provider.VerifyIDToken(ctx, sign(map[string]any{"iss": fake.URL, "aud": "app", "nonce": "n1"}), "n1")
+	assert.NoError(t, err)
+
+	_, err = provider.VerifyIDToken(ctx, sign(map[string]any{"iss": fake.URL, "aud": "other-app", "nonce": "n1"}), "n1")
+	assert.ErrorIs(t, err, oauth.ErrInvalidIDToken)
+	_, err = provider.VerifyIDToken(ctx, sign(map[string]any{"iss": "https://evil.example.com", "aud": "app", "nonce": "n1"}), "n1")
+	assert.ErrorIs(t, err, oauth.ErrInvalidIDToken)
+	_, err = provider.VerifyIDToken(ctx, sign(map[string]any{"iss": fake.URL, "aud": "app", "nonce": "n1"}), "n2")
+	assert.ErrorIs(t, err, oauth.ErrInvalidIDToken)
+
+	// A token signed with the app's HMAC secret is not the provider's
+	hmacToken, err := (&security.JwtService{SecretKey: "secret"}).Sign(map[string]any{"iss": fake.URL, "aud": "app", "nonce": "n1"})
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, hmacToken, "n1")
+	assert.ErrorIs(t, err, oauth.ErrInvalidIDToken)
+}
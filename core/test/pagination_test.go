@@ -0,0 +1,65 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageParams(t *testing.T) {
+	app := core.NewApp()
+	cfg := core.PaginationConfig{DefaultLimit: 10, MaxLimit: 50, SortableFields: []string{"created_at", "name"}}
+
+	app.Get("/products", func(c *fiber.Ctx) error {
+		params, err := core.ParsePageParams(c, cfg)
+		if err != nil {
+			return err
+		}
+		page := core.NewPage([]string{"a", "b"}, params, 45)
+		return c.JSON(core.HttpPaginated("Products", page))
+	})
+
+	status, body := decodeResponse(t, app, "/products?page=2&limit=20&sort=-created_at,name")
+	assert.Equal(t, 200, status)
+	data := body.Data.(map[string]interface{})
+	assert.Equal(t, float64(2), data["page"])
+	assert.Equal(t, float64(3), data["total_pages"])
+	assert.Equal(t, true, data["has_more"])
+
+	status, body = decodeResponse(t, app, "/products?limit=500")
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "limit must not exceed 50", body.Message)
+
+	status, _ = decodeResponse(t, app, "/products?sort=password")
+	assert.Equal(t, 400, status)
+
+	// Configs without MaxLimit are still bounded by the default one
+	app.Get("/unbounded", func(c *fiber.Ctx) error {
+		_, err := core.ParsePageParams(c, core.PaginationConfig{DefaultLimit: 10})
+		return err
+	})
+	status, body = decodeResponse(t, app, "/unbounded?limit=1000000")
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "limit must not exceed 100", body.Message)
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec := core.NewCursorCodec([]byte("cursor-secret"))
+
+	cursor, err := codec.Encode(map[string]any{"id": int64(9007199254740993), "created_at": "2026-10-18T10:00:00Z"})
+	require.NoError(t, err)
+
+	position, err := codec.Decode(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), position["id"])
+
+	forged, err := core.NewCursorCodec([]byte("other-secret")).Encode(map[string]any{"id": 1})
+	require.NoError(t, err)
+	_, err = codec.Decode(forged)
+	assert.ErrorIs(t, err, core.ErrInvalidCursor)
+}
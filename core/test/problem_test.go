@@ -0,0 +1,104 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func problemRequest(t *testing.T, app *core.App, method string, path string, body string) (int, string, map[string]any) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var members map[string]any
+	require.NoError(t, json.Unmarshal(raw, &members))
+	return resp.StatusCode, resp.Header.Get("Content-Type"), members
+}
+
+func TestProblemMembers(t *testing.T) {
+	problem := core.NewProblem(core.HttpStatusConflict, "Email already registered").
+		WithType("https://example.com/problems/duplicate-email").
+		With("email", "taken@example.com")
+
+	encoded, err := json.Marshal(problem)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "https://example.com/problems/duplicate-email",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "Email already registered",
+		"email": "taken@example.com"
+	}`, string(encoded))
+
+	assert.Equal(t, "Too Many Requests", core.StatusTitle(core.HttpStatusTooManyRequests))
+	assert.Equal(t, "Unprocessable Entity", core.ValidationProblem(nil).Title)
+}
+
+func TestProblemDetailsErrorHandler(t *testing.T) {
+	app := core.NewApp()
+	app.UseProblemDetails(true)
+
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		_, err := core.Bind[PlaceOrderDto](c)
+		return err
+	})
+	app.Get("/orders/:id", func(c *fiber.Ctx) error {
+		return core.NotFound("Order not found")
+	})
+	app.Get("/limits", func(c *fiber.Ctx) error {
+		return core.NewProblem(core.HttpStatusTooManyRequests, "Slow down").With("retry_after", 30)
+	})
+
+	status, contentType, members := problemRequest(t, app, "GET", "/orders/9", "")
+	assert.Equal(t, 404, status)
+	assert.Equal(t, core.ProblemMediaType, contentType)
+	assert.Equal(t, "about:blank", members["type"])
+	assert.Equal(t, "Not Found", members["title"])
+	assert.Equal(t, "Order not found", members["detail"])
+	assert.Equal(t, "/orders/9", members["instance"])
+
+	status, _, members = problemRequest(t, app, "POST", "/orders", `{"customer":{"email":"x"},"items":[{"sku":""}]}`)
+	assert.Equal(t, 422, status)
+	errs := members["errors"].([]any)
+	require.NotEmpty(t, errs)
+	assert.Equal(t, "/customer/email", errs[0].(map[string]any)["pointer"])
+
+	status, _, members = problemRequest(t, app, "GET", "/limits", "")
+	assert.Equal(t, 429, status)
+	assert.Equal(t, float64(30), members["retry_after"])
+}
+
+var errQuotaExceeded = core.NewProblem(core.HttpStatusTooManyRequests, "Quota exceeded")
+
+func TestSharedProblemIsNotMutated(t *testing.T) {
+	app := core.NewApp()
+	app.UseProblemDetails(true)
+	app.Get("/quota/:id", func(c *fiber.Ctx) error {
+		return errQuotaExceeded
+	})
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2", "3", "4"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			_, _, members := problemRequest(t, app, "GET", "/quota/"+id, "")
+			assert.Equal(t, "/quota/"+id, members["instance"])
+		}(id)
+	}
+	wg.Wait()
+	assert.Empty(t, errQuotaExceeded.Instance)
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/security/ratelimit"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewFixedWindow(2, time.Minute), ratelimit.NewMemoryStore(), ratelimit.ByHeader("X-Client"))
+
+	app := core.NewApp()
+	app.Post("/login", limiter.Use(), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	login := func(client string) (int, string, string) {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.Header.Set("X-Client", client)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get("RateLimit-Remaining"), resp.Header.Get("Retry-After")
+	}
+
+	status, remaining, _ := login("a")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "1", remaining)
+	status, remaining, _ = login("a")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0", remaining)
+
+	status, _, retryAfter := login("a")
+	assert.Equal(t, 429, status)
+	assert.NotEmpty(t, retryAfter)
+
+	status, _, _ = login("b")
+	assert.Equal(t, 200, status)
+}
+
+func TestRateLimitGuard(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewTokenBucket(1, time.Hour), ratelimit.NewMemoryStore(), nil)
+
+	app := core.NewApp()
+	app.Get("/export", func(c *fiber.Ctx) error {
+		if !limiter.CanActivate(c) {
+			return core.TooManyRequests("Export already requested")
+		}
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/export", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/export", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "3600", resp.Header.Get("Retry-After"))
+}
+
+// failingRateStore simulates an unavailable store
+type failingRateStore struct{}
+
+func (failingRateStore) Increment(context.Context, string, time.Duration) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (failingRateStore) Get(context.Context, string) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (failingRateStore) TakeToken(context.Context, string, int, float64, time.Time) (float64, bool, error) {
+	return 0, false, errors.New("store unavailable")
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewFixedWindow(5, time.Minute), failingRateStore{}, nil)
+
+	app := core.NewApp()
+	app.Post("/login", limiter.Use(), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	// Fails closed by default, still telling clients when to retry
+	resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "5", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	limiter.FailOpen = true
+	resp, err = app.Test(httptest.NewRequest("POST", "/login", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestRateLimitAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+	start := time.Now().Truncate(time.Minute)
+
+	// Token bucket: bursts up to capacity, then refills continuously
+	bucket := ratelimit.NewTokenBucket(3, 3*time.Second)
+	for i := 0; i < 3; i++ {
+		result, err := bucket.Allow(ctx, store, "bucket", start)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err := bucket.Allow(ctx, store, "bucket", start)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	result, err = bucket.Allow(ctx, store, "bucket", start.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Sliding window: requests at the end of a window still count early in the next one
+	sliding := ratelimit.NewSlidingWindow(4, time.Hour)
+	hour := time.Now().Truncate(time.Hour)
+	for i := 0; i < 4; i++ {
+		result, err = sliding.Allow(ctx, store, "sliding", hour)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err = sliding.Allow(ctx, store, "sliding", hour)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID        int64      `db:"id,pk,auto"`
+	SKU       string     `db:"sku"`
+	Price     int        `db:"price"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+	Internal  string
+}
+
+// recordingQuerier captures the statements sent to the database
+type recordingQuerier struct {
+	database.Querier
+	sql  []string
+	args [][]any
+}
+
+func (q *recordingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	q.sql = append(q.sql, sql)
+	q.args = append(q.args, args)
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (q *recordingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.sql = append(q.sql, sql)
+	q.args = append(q.args, args)
+	return emptyRows{}, nil
+}
+
+// emptyRows is a result set without rows
+type emptyRows struct{ pgx.Rows }
+
+func (emptyRows) Next() bool { return false }
+func (emptyRows) Err() error { return nil }
+func (emptyRows) Close()     {}
+func (emptyRows) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag("SELECT 0")
+}
+
+func TestQueryBuilder(t *testing.T) {
+	sql, args := database.Select("public.products", "id", "sku").
+		Where(database.Eq("sku", "A-1"), database.Or(database.Gt("price", 10), database.IsNull("price"))).
+		OrderBy(database.Sort{Column: "price", Desc: true}).
+		Limit(20).
+		Offset(40).
+		Build()
+
+	assert.Equal(t, `SELECT "id", "sku" FROM "public"."products" WHERE ("sku" = $1) AND (("price" > $2) OR ("price" IS NULL)) ORDER BY "price" DESC LIMIT $3 OFFSET $4`, sql)
+	assert.Equal(t, []any{"A-1", 10, 20, 40}, args)
+
+	// Placeholders are only numbered outside quotes, and ?? is a literal ?
+	sql, args = database.Select("products", "id").
+		Where(database.Expr(`data ?? ? AND note <> 'why?' AND "odd?" = ? AND body <> $$a?b$$ AND tag = E'it\\'s?'`, "color", 1)).
+		Build()
+	assert.Equal(t, `SELECT "id" FROM "products" WHERE data ? $1 AND note <> 'why?' AND "odd?" = $2 AND body <> $$a?b$$ AND tag = E'it\\'s?'`, sql)
+	assert.Equal(t, []any{"color", 1}, args)
+
+	sql, args = database.Insert("products", "sku", "price").Values("A-1", 5).Values("B-2", 7).Returning("id").Build()
+	assert.Equal(t, `INSERT INTO "products" ("sku", "price") VALUES ($1, $2), ($3, $4) RETURNING "id"`, sql)
+	assert.Equal(t, []any{"A-1", 5, "B-2", 7}, args)
+}
+
+func TestRepositorySQL(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := database.NewRepository[Product](db, "products")
+
+	assert.Equal(t, []string{"id", "sku", "price", "deleted_at"}, repo.Columns())
+
+	query, err := repo.Query(database.FindOptions{
+		Where: []database.Condition{database.In("sku", []string{"A-1", "B-2"})},
+		Sort:  []database.Sort{{Column: "sku"}},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	sql, _ := query.Build()
+	assert.Equal(t, `SELECT "id", "sku", "price", "deleted_at" FROM "products" WHERE ("sku" = ANY($1)) AND ("deleted_at" IS NULL) ORDER BY "sku" ASC LIMIT $2`, sql)
+
+	_, err = repo.Query(database.FindOptions{Sort: []database.Sort{{Column: "password"}}})
+	assert.Error(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, repo.Update(ctx, &Product{ID: 7, SKU: "A-1", Price: 12}))
+	require.NoError(t, repo.Delete(ctx, 7))
+	require.NoError(t, repo.ForceDelete(ctx, 7))
+
+	assert.Equal(t, []string{
+		`UPDATE "products" SET "sku" = $1, "price" = $2 WHERE ("id" = $3) AND ("deleted_at" IS NULL)`,
+		`UPDATE "products" SET "deleted_at" = $1 WHERE ("id" = $2) AND ("deleted_at" IS NULL)`,
+		`DELETE FROM "products" WHERE "id" = $1`,
+	}, db.sql)
+}
+
+func TestPaginateCursorLimit(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := database.NewRepository[Product](db, "products")
+	codec := core.NewCursorCodec([]byte("cursor-secret"))
+	ctx := context.Background()
+
+	// PageParams built by hand get the default page size
+	page, err := repo.PaginateCursor(ctx, core.PageParams{}, codec)
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+	require.Len(t, db.args, 1)
+	assert.Equal(t, core.DefaultPaginationConfig.DefaultLimit+1, db.args[0][len(db.args[0])-1])
+
+	_, err = repo.PaginateCursor(ctx, core.PageParams{Limit: -1}, codec)
+	var exception *core.HttpException
+	require.ErrorAs(t, err, &exception)
+	assert.Equal(t, 400, exception.Status)
+}
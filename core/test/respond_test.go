@@ -0,0 +1,114 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ReportRow struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Notes string `json:"-"`
+}
+
+func respondRequest(t *testing.T, app *core.App, path string, accept string) (int, string, string) {
+	req := httptest.NewRequest("GET", path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+}
+
+func TestRespondNegotiation(t *testing.T) {
+	app := core.NewApp()
+	rows := []ReportRow{{ID: 1, Name: "Widget", Notes: "internal"}, {ID: 2, Name: "Gadget, large"}}
+	app.Get("/reports", func(c *fiber.Ctx) error {
+		return core.Respond(c, core.HttpSuccessWithData("Reports", core.HttpStatusOK, rows))
+	})
+	app.Get("/reports/1", func(c *fiber.Ctx) error {
+		return core.Respond(c, core.HttpSuccessWithData("Report", core.HttpStatusCreated, rows[0]))
+	})
+
+	status, contentType, body := respondRequest(t, app, "/reports/1", "")
+	assert.Equal(t, 201, status)
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{"code":201,"message":"Report","status":true,"data":{"id":1,"name":"Widget"}}`, body)
+
+	_, contentType, body = respondRequest(t, app, "/reports/1", "text/html;q=0.9, application/xml")
+	assert.Equal(t, "application/xml", contentType)
+	assert.Contains(t, body, "<response><code>201</code><data><id>1</id><name>Widget</name></data><message>Report</message><status>true</status></response>")
+
+	_, contentType, body = respondRequest(t, app, "/reports/1", "application/msgpack")
+	assert.Equal(t, "application/msgpack", contentType)
+	var decoded map[string]any
+	require.NoError(t, msgpack.Unmarshal([]byte(body), &decoded))
+	assert.Equal(t, "Report", decoded["message"])
+
+	_, contentType, body = respondRequest(t, app, "/reports", "text/csv")
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, "id,name\n1,Widget\n2,\"Gadget, large\"\n", body)
+
+	status, _, _ = respondRequest(t, app, "/reports/1", "text/csv")
+	assert.Equal(t, 406, status)
+
+	core.RegisterEncoder(core.Encoder{MediaType: "text/plain", Encode: func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, v.(core.HttpResponseType[interface{}]).Message)
+		return err
+	}})
+	t.Cleanup(func() { core.RemoveEncoder("text/plain") })
+	_, contentType, body = respondRequest(t, app, "/reports/1", "text/plain")
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, "Report", body)
+}
+
+func TestRespondXMLFallsBackToJSON(t *testing.T) {
+	app := core.NewApp()
+	app.Get("/stats", func(c *fiber.Ctx) error {
+		return core.Respond(c, core.HttpSuccessWithData("Stats", core.HttpStatusOK, fiber.Map{"total": 3, "by_status": fiber.Map{"paid": 2}}))
+	})
+	app.Get("/labels", func(c *fiber.Ctx) error {
+		return core.Respond(c, core.HttpSuccessWithData("Labels", core.HttpStatusOK, fiber.Map{"first name": "Ada"}))
+	})
+
+	status, contentType, body := respondRequest(t, app, "/stats", "application/xml")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "application/xml", contentType)
+	assert.Contains(t, body, "<data><by_status><paid>2</paid></by_status><total>3</total></data>")
+
+	// Keys that are not XML names cannot be represented, so JSON is sent instead
+	status, contentType, body = respondRequest(t, app, "/labels", "application/xml")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{"code":200,"message":"Labels","status":true,"data":{"first name":"Ada"}}`, body)
+}
+
+func TestRespondProblemEnvelope(t *testing.T) {
+	app := core.NewApp()
+	app.UseProblemDetails(true)
+	app.Get("/orders/:id", func(c *fiber.Ctx) error {
+		return core.Respond(c, core.HttpError("Order 7 does not exist", core.HttpStatusNotFound))
+	})
+
+	status, contentType, body := respondRequest(t, app, "/orders/7", "application/json")
+	assert.Equal(t, 404, status)
+	assert.Equal(t, "application/problem+json", contentType)
+
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &problem))
+	assert.Equal(t, "Not Found", problem["title"])
+	assert.Equal(t, "Order 7 does not exist", problem["detail"])
+	assert.Equal(t, "/orders/7", problem["instance"])
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"github.com/Alexigbokwe/goNextCore/core/database/seed"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedSeeder struct {
+	name      string
+	dependsOn []string
+}
+
+func (s *namedSeeder) Name() string        { return s.name }
+func (s *namedSeeder) DependsOn() []string { return s.dependsOn }
+func (s *namedSeeder) Seed(ctx context.Context, db database.Querier) error {
+	return nil
+}
+
+func TestSeederPlan(t *testing.T) {
+	container := core.NewContainer()
+	seed.Register(container, &namedSeeder{name: "users", dependsOn: []string{"roles"}})
+	registry := seed.Register(container, &namedSeeder{name: "roles"}, &namedSeeder{name: "orders", dependsOn: []string{"users"}})
+
+	plan, err := registry.Plan("orders")
+	require.NoError(t, err)
+	names := make([]string, len(plan))
+	for i, seeder := range plan {
+		names[i] = seeder.Name()
+	}
+	assert.Equal(t, []string{"roles", "users", "orders"}, names)
+
+	registry.Add(&namedSeeder{name: "roles", dependsOn: []string{"orders"}})
+	_, err = registry.Plan()
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestLoadFixtures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"roles.yaml": {Data: []byte("roles:\n  - {id: 1, name: admin}\nusers:\n  - {id: 1, email: admin@example.com, role_id: 1}\n")},
+		"tags.json":  {Data: []byte(`{"tags": [{"id": 3, "label": "new"}]}`)},
+	}
+	db := &recordingQuerier{}
+
+	err := seed.LoadFixtures(context.Background(), db, fsys, "roles.yaml", "tags.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`INSERT INTO "roles" ("id", "name") VALUES ($1, $2)`,
+		`INSERT INTO "users" ("email", "id", "role_id") VALUES ($1, $2, $3)`,
+		`INSERT INTO "tags" ("id", "label") VALUES ($1, $2)`,
+	}, db.sql)
+	assert.Equal(t, []any{1, "admin"}, db.args[0])
+}
@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/config"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutualTLSListener(t *testing.T) {
+	serverCert, serverPool, err := SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	clientCert, clientPool, err := SelfSignedCertificate("billing-service")
+	require.NoError(t, err)
+
+	app := core.NewApp()
+	guard := &security.ClientCertGuard{AllowedCommonNames: []string{"billing-service"}}
+	app.Get("/whoami", func(c *fiber.Ctx) error {
+		if !guard.CanActivate(c) {
+			return core.Forbidden("Client certificate rejected")
+		}
+		return c.SendString(security.ClientCertificate(c).Subject.CommonName)
+	})
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientPool,
+	})
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      serverPool,
+			Certificates: []tls.Certificate{clientCert},
+		}},
+	}
+
+	resp, err := client.Get("https://" + ln.Addr().String() + "/whoami")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "billing-service", string(body))
+}
+
+// writeCertificateFiles stores cert and its key as PEM files in a temp directory
+func writeCertificateFiles(t *testing.T, cert tls.Certificate) (string, string) {
+	dir := t.TempDir()
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600))
+	return certFile, keyFile
+}
+
+// freePort returns a TCP port that was free a moment ago
+func freePort(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+// serve runs listen in the background and shuts the app down at the end of the test
+func serve(t *testing.T, app *core.App, listen func() error) {
+	done := make(chan error, 1)
+	go func() { done <- listen() }()
+	t.Cleanup(func() {
+		require.NoError(t, app.Shutdown())
+		require.NoError(t, <-done)
+	})
+}
+
+// getEventually retries until the server accepts the request
+func getEventually(t *testing.T, client *http.Client, url string) string {
+	var lastErr error
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		resp, err := client.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	require.NoError(t, lastErr)
+	return ""
+}
+
+func pingApp() *core.App {
+	app := core.NewAppWithConfig(&config.Config{})
+	app.Get("/ping", func(c *fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+	return app
+}
+
+func TestStartServesTLSFromConfig(t *testing.T) {
+	cert, pool, err := SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	certFile, keyFile := writeCertificateFiles(t, cert)
+
+	app := pingApp()
+	app.Config.Server = config.ServerConfig{Host: "127.0.0.1", Port: freePort(t), TLSCertFile: certFile, TLSKeyFile: keyFile}
+	serve(t, app, app.Start)
+
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	assert.Equal(t, "pong", getEventually(t, client, "https://"+app.Config.Server.Addr()+"/ping"))
+}
+
+func TestListenTLS(t *testing.T) {
+	cert, pool, err := SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	certFile, keyFile := writeCertificateFiles(t, cert)
+
+	app := pingApp()
+	addr := "127.0.0.1:" + freePort(t)
+	serve(t, app, func() error { return app.ListenTLS(addr, certFile, keyFile) })
+
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	assert.Equal(t, "pong", getEventually(t, client, "https://"+addr+"/ping"))
+}
+
+func TestStartServesUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	// A stale socket file from a previous run is replaced
+	require.NoError(t, os.WriteFile(socket, nil, 0o600))
+
+	app := pingApp()
+	app.Config.Server = config.ServerConfig{UnixSocket: socket}
+	serve(t, app, app.Start)
+
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	assert.Equal(t, "pong", getEventually(t, client, "http://unix/ping"))
+}
+
+func TestStartRejectsInvalidServerConfig(t *testing.T) {
+	app := core.NewApp()
+	app.Config = nil
+	assert.ErrorContains(t, app.Start(), "no configuration")
+
+	cert, _, err := SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	certFile, keyFile := writeCertificateFiles(t, cert)
+
+	app = core.NewApp()
+	app.Config.Server = config.ServerConfig{UnixSocket: filepath.Join(t.TempDir(), "app.sock"), TLSCertFile: certFile, TLSKeyFile: keyFile}
+	assert.ErrorContains(t, app.Start(), "SERVER_UNIX_SOCKET")
+
+	app.Config.Server = config.ServerConfig{UnixSocket: filepath.Join(t.TempDir(), "app.sock")}
+	app.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	assert.ErrorContains(t, app.Start(), "SERVER_UNIX_SOCKET")
+
+	app = core.NewApp()
+	app.Config.Server = config.ServerConfig{Host: "127.0.0.1", Port: "0", TLSCertFile: certFile}
+	assert.ErrorContains(t, app.Start(), "must be set together")
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	cert, _, err := SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	certFile, keyFile := writeCertificateFiles(t, cert)
+	caFile, _ := writeCertificateFiles(t, cert)
+
+	tlsConfig, err := core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	require.Len(t, tlsConfig.Certificates, 1)
+
+	tlsConfig, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+	assert.NotNil(t, tlsConfig.ClientCAs)
+
+	tlsConfig, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile, TLSClientAuth: "verify-if-given"})
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, tlsConfig.ClientAuth)
+
+	_, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientAuth: "sometimes"})
+	assert.Error(t, err)
+	_, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile})
+	assert.Error(t, err)
+	_, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+	_, err = core.NewTLSConfig(config.ServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: keyFile})
+	assert.ErrorContains(t, err, "no certificates")
+}
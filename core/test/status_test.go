@@ -0,0 +1,85 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusType(t *testing.T) {
+	status := core.Status(core.HttpStatusServiceUnavailable)
+	assert.Equal(t, "Service Unavailable", status.Text())
+	assert.Equal(t, "503 Service Unavailable", status.String())
+	assert.True(t, status.IsServerError())
+	assert.True(t, status.IsRetryable())
+	assert.False(t, core.Status(core.HttpStatusNotFound).IsRetryable())
+	assert.True(t, core.Status(core.HttpStatusNoContent).IsSuccess())
+
+	type job struct {
+		Status core.Status `json:"status"`
+	}
+
+	encoded, err := json.Marshal(job{Status: core.HttpStatusAccepted})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":202}`, string(encoded))
+
+	type namedJob struct {
+		Status core.StatusText `json:"status"`
+	}
+
+	encoded, err = json.Marshal(namedJob{Status: core.HttpStatusAccepted})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"Accepted"}`, string(encoded))
+	encoded, err = json.Marshal(namedJob{Status: 599})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":599}`, string(encoded))
+
+	var named namedJob
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"not found"}`), &named))
+	assert.Equal(t, core.StatusText(404), named.Status)
+
+	var decoded job
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"too_many_requests"}`), &decoded))
+	assert.Equal(t, core.Status(429), decoded.Status)
+	require.NoError(t, json.Unmarshal([]byte(`{"status":418}`), &decoded))
+	assert.Equal(t, core.Status(418), decoded.Status)
+	assert.Error(t, json.Unmarshal([]byte(`{"status":"Very Bad"}`), &decoded))
+}
+
+func TestStatusResponseHelpers(t *testing.T) {
+	app := core.NewApp()
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		return core.CreatedAt(c, "/orders/7", fiber.Map{"id": 7})
+	})
+	app.Post("/exports", func(c *fiber.Ctx) error {
+		return core.Accepted(c, "/exports/42/status")
+	})
+	app.Delete("/orders/7", func(c *fiber.Ctx) error {
+		return core.NoContent(c)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/orders", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "/orders/7", resp.Header.Get("Location"))
+	var body core.HttpResponseType[map[string]any]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 201, body.Code)
+	assert.Equal(t, float64(7), body.Data["id"])
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/exports", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 202, resp.StatusCode)
+	assert.Equal(t, "/exports/42/status", resp.Header.Get("Location"))
+
+	resp, err = app.Test(httptest.NewRequest("DELETE", "/orders/7", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+	assert.Equal(t, int64(0), resp.ContentLength)
+}
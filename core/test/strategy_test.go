@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticationStrategies(t *testing.T) {
+	jwtService := &security.JwtService{SecretKey: "test_secret"}
+
+	apiKeys := security.NewMemoryAPIKeyStore()
+	apiKey, hash, err := security.GenerateAPIKey("sk_test_")
+	require.NoError(t, err)
+	apiKeys.Add(hash, &security.Principal{ID: "reporting-job", Roles: []string{"reports:read"}})
+
+	basic := security.NewBasicStrategy("admin", func(ctx context.Context, username string, password string) (*security.Principal, error) {
+		if username == "ops" && password == "s3cret" {
+			return &security.Principal{ID: "ops", Type: "user"}, nil
+		}
+		return nil, security.ErrInvalidCredentials
+	})
+
+	signing := security.NewHMACStrategy(func(ctx context.Context, keyID string) ([]byte, *security.Principal, error) {
+		if keyID != "billing" {
+			return nil, nil, security.ErrInvalidCredentials
+		}
+		return []byte("shared-secret"), &security.Principal{ID: "billing"}, nil
+	})
+	signing.Replays = cache.NewMemoryStore()
+
+	jwtStrategy := security.NewJwtStrategy(jwtService).FromCookie("session")
+	apiKeyStrategy := security.NewAPIKeyStrategy(apiKeys)
+	apiKeyStrategy.Query = "api_key"
+
+	app := core.NewApp()
+	auth := security.Authenticate(jwtStrategy, apiKeyStrategy, basic, signing)
+	app.All("/whoami", auth.Use(), func(c *fiber.Ctx) error {
+		user := security.CurrentUser(c)
+		return c.SendString(user.Strategy + ":" + user.Type + ":" + user.ID)
+	})
+
+	call := func(req *fiberRequest) (int, string) {
+		resp, err := app.Test(req.build())
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	token, err := jwtService.Sign(map[string]interface{}{"sub": "42", "roles": []string{"admin"}})
+	require.NoError(t, err)
+
+	status, body := call(&fiberRequest{headers: map[string]string{"Authorization": "Bearer " + token}})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "jwt:user:42", body)
+
+	_, body = call(&fiberRequest{headers: map[string]string{"Cookie": "session=" + token}})
+	assert.Equal(t, "jwt:user:42", body)
+
+	_, body = call(&fiberRequest{headers: map[string]string{"X-API-Key": apiKey}})
+	assert.Equal(t, "api_key:api_key:reporting-job", body)
+
+	_, body = call(&fiberRequest{path: "/whoami?api_key=" + apiKey})
+	assert.Equal(t, "api_key:api_key:reporting-job", body)
+
+	_, body = call(&fiberRequest{headers: map[string]string{"Authorization": "Basic b3BzOnMzY3JldA=="}})
+	assert.Equal(t, "basic:user:ops", body)
+
+	signed := httptest.NewRequest("POST", "/whoami?x=1", strings.NewReader(`{"amount":10}`))
+	require.NoError(t, security.SignRequest(signed, "billing", []byte("shared-secret")))
+	resp, err := app.Test(signed)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// A captured request is accepted only once
+	replayed := httptest.NewRequest("POST", "/whoami?x=1", strings.NewReader(`{"amount":10}`))
+	replayed.Header = signed.Header.Clone()
+	resp, err = app.Test(replayed)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	tampered := httptest.NewRequest("POST", "/whoami?x=1", strings.NewReader(`{"amount":10}`))
+	require.NoError(t, security.SignRequest(tampered, "billing", []byte("shared-secret")))
+	tampered.Body = httptest.NewRequest("POST", "/", strings.NewReader(`{"amount":99}`)).Body
+	resp, err = app.Test(tampered)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Wrong credentials do not fall back to the next strategy
+	status, _ = call(&fiberRequest{headers: map[string]string{"Authorization": "Bearer invalid", "X-API-Key": apiKey}})
+	assert.Equal(t, 401, status)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/whoami", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, `Bearer, Basic realm="admin", charset="UTF-8"`, resp.Header.Get("WWW-Authenticate"))
+}
+
+type fiberRequest struct {
+	path    string
+	headers map[string]string
+}
+
+func (r *fiberRequest) build() *http.Request {
+	path := r.path
+	if path == "" {
+		path = "/whoami"
+	}
+	req := httptest.NewRequest("GET", path, nil)
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+	return req
+}
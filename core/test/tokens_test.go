@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/Alexigbokwe/goNextCore/core/cache"
+	"github.com/Alexigbokwe/goNextCore/core/security"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenService() *security.TokenService {
+	jwtService := &security.JwtService{SecretKey: "test_secret"}
+	return security.NewTokenService(jwtService, security.NewCacheTokenStore(cache.NewMemoryStore()))
+}
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	ctx := context.Background()
+	tokens := newTokenService()
+
+	login, err := tokens.Issue(ctx, "user-1", map[string]any{"role": "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", login.TokenType)
+	assert.Equal(t, int64(900), login.ExpiresIn)
+
+	rotated, err := tokens.Refresh(ctx, login.RefreshToken)
+	require.NoError(t, err)
+	claims, err := tokens.Jwt.Verify(rotated.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", claims["role"])
+	assert.Equal(t, "user-1", claims["sub"])
+
+	// Replaying the rotated token revokes the family, including the newest tokens
+	_, err = tokens.Refresh(ctx, login.RefreshToken)
+	assert.ErrorIs(t, err, security.ErrRefreshTokenReused)
+	_, err = tokens.Refresh(ctx, rotated.RefreshToken)
+	assert.ErrorIs(t, err, security.ErrInvalidRefreshToken)
+
+	revoked, err := tokens.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = tokens.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, security.ErrInvalidRefreshToken)
+}
+
+func TestLogoutEndpoints(t *testing.T) {
+	ctx := context.Background()
+	tokens := newTokenService()
+	guard := &security.AuthGuard{JwtService: tokens.Jwt}
+
+	app := core.NewApp()
+	requireAuth := func(c *fiber.Ctx) error {
+		if !guard.CanActivate(c) {
+			return core.Unauthorized("Unauthorized")
+		}
+		return c.Next()
+	}
+	app.Post("/auth/refresh", security.RefreshHandler(tokens))
+	app.Post("/auth/logout", requireAuth, security.LogoutHandler(tokens))
+	app.Post("/auth/logout-all", requireAuth, security.LogoutAllHandler(tokens))
+	app.Get("/me", requireAuth, func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	call := func(method string, path string, token string, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	phone, err := tokens.Issue(ctx, "user-2", nil)
+	require.NoError(t, err)
+	laptop, err := tokens.Issue(ctx, "user-2", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, call("GET", "/me", phone.AccessToken, ""))
+	assert.Equal(t, 204, call("POST", "/auth/logout", phone.AccessToken, ""))
+	assert.Equal(t, 401, call("GET", "/me", phone.AccessToken, ""))
+	assert.Equal(t, 401, call("POST", "/auth/refresh", "", `{"refresh_token":"`+phone.RefreshToken+`"}`))
+
+	// The other session is unaffected until logout-all
+	assert.Equal(t, 200, call("GET", "/me", laptop.AccessToken, ""))
+	assert.Equal(t, 200, call("POST", "/auth/refresh", "", `{"refresh_token":"`+laptop.RefreshToken+`"}`))
+	assert.Equal(t, 204, call("POST", "/auth/logout-all", laptop.AccessToken, ""))
+	assert.Equal(t, 401, call("GET", "/me", laptop.AccessToken, ""))
+}
+
+func TestConcurrentRefreshWithSameToken(t *testing.T) {
+	ctx := context.Background()
+	tokens := newTokenService()
+	login, err := tokens.Issue(ctx, "user-3", nil)
+	require.NoError(t, err)
+
+	const attempts = 8
+	var wg sync.WaitGroup
+	results := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tokens.Refresh(ctx, login.RefreshToken)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, security.ErrRefreshTokenReused)
+		}
+	}
+	assert.Equal(t, 1, succeeded, "only one refresh may win the rotation")
+}
+
+func TestLogoutAllThenImmediateLogin(t *testing.T) {
+	ctx := context.Background()
+	tokens := newTokenService()
+
+	before, err := tokens.Issue(ctx, "user-4", nil)
+	require.NoError(t, err)
+	require.NoError(t, tokens.LogoutAll(ctx, "user-4"))
+	after, err := tokens.Issue(ctx, "user-4", nil)
+	require.NoError(t, err)
+
+	oldClaims, err := tokens.Jwt.Verify(before.AccessToken)
+	require.NoError(t, err)
+	revoked, err := tokens.IsRevoked(ctx, oldClaims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Issued within the same second as the logout, yet after it
+	newClaims, err := tokens.Jwt.Verify(after.AccessToken)
+	require.NoError(t, err)
+	revoked, err = tokens.IsRevoked(ctx, newClaims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = tokens.Refresh(ctx, after.RefreshToken)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDB records transaction lifecycle calls instead of talking to Postgres
+type fakeDB struct {
+	database.Querier
+	events         []string
+	commitFailures int
+}
+
+func (d *fakeDB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	if opts.AccessMode == pgx.ReadOnly {
+		d.events = append(d.events, "begin-read-only")
+	} else {
+		d.events = append(d.events, "begin")
+	}
+	return &fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDB
+	nested bool
+}
+
+func (t *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	t.db.events = append(t.db.events, "savepoint")
+	return &fakeTx{db: t.db, nested: true}, nil
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	if t.nested {
+		t.db.events = append(t.db.events, "release")
+		return nil
+	}
+	if t.db.commitFailures > 0 {
+		t.db.commitFailures--
+		t.db.events = append(t.db.events, "conflict")
+		return &pgconn.PgError{Code: "40001"}
+	}
+	t.db.events = append(t.db.events, "commit")
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if t.nested {
+		t.db.events = append(t.db.events, "rollback-savepoint")
+	} else {
+		t.db.events = append(t.db.events, "rollback")
+	}
+	return nil
+}
+
+func TestTxManagerNestedSavepoints(t *testing.T) {
+	db := &fakeDB{}
+	tm := database.NewTxManager(db)
+	errInner := errors.New("inner failed")
+
+	err := tm.WithTx(context.Background(), func(ctx context.Context) error {
+		_, ok := tm.Querier(ctx).(pgx.Tx)
+		assert.True(t, ok, "querier should be the ambient transaction")
+
+		innerErr := tm.WithTx(ctx, func(ctx context.Context) error {
+			return errInner
+		})
+		assert.ErrorIs(t, innerErr, errInner)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"begin", "savepoint", "rollback-savepoint", "commit"}, db.events)
+	assert.Equal(t, db, tm.Querier(context.Background()))
+}
+
+func TestTxManagerRetriesSerializationFailures(t *testing.T) {
+	db := &fakeDB{commitFailures: 2}
+	tm := database.NewTxManager(db)
+	tm.Backoff = 0
+
+	calls := 0
+	err := tm.WithTxOptions(context.Background(), database.TxOptions{
+		IsoLevel: pgx.Serializable,
+		ReadOnly: true,
+	}, func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "commit", db.events[len(db.events)-1])
+	assert.Equal(t, "begin-read-only", db.events[0])
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Alexigbokwe/goNextCore/core"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SignupDto struct {
+	Email    string `json:"email" validate:"required,email,unique_email"`
+	Password string `json:"password" validate:"min=8"`
+	Confirm  string `json:"confirm"`
+	Seats    int    `json:"seats" validate:"multiple_of=5"`
+}
+
+func TestCustomValidationRules(t *testing.T) {
+	taken := map[string]bool{"taken@example.com": true}
+
+	require.NoError(t, core.RegisterValidation("unique_email", func(fl validator.FieldLevel) bool {
+		return !taken[fl.Field().String()]
+	}, "{0} is already registered"))
+	require.NoError(t, core.RegisterValidation("multiple_of", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%5 == 0
+	}, "{0} must be a multiple of {1}"))
+	require.NoError(t, core.RegisterTranslation("fr", "unique_email", "{0} est déjà utilisé"))
+
+	core.RegisterStructValidation(func(sl validator.StructLevel) {
+		dto := sl.Current().Interface().(SignupDto)
+		if dto.Password != dto.Confirm {
+			sl.ReportError(dto.Confirm, "confirm", "Confirm", "eqfield", "password")
+		}
+	}, SignupDto{})
+
+	errs := core.ValidateStruct(SignupDto{Email: "taken@example.com", Password: "secret123", Confirm: "nope", Seats: 3})
+	messages := map[string]string{}
+	for _, e := range errs {
+		messages[e.Field] = e.Message
+	}
+	assert.Equal(t, "email is already registered", messages["email"])
+	assert.Equal(t, "seats must be a multiple of 5", messages["seats"])
+	assert.Equal(t, "confirm must be equal to password", messages["confirm"])
+
+	app := core.NewApp()
+	app.Post("/signup", func(c *fiber.Ctx) error {
+		_, err := core.Bind[SignupDto](c)
+		return err
+	})
+
+	req := httptest.NewRequest("POST", "/signup", strings.NewReader(`{"email":"taken@example.com","password":"secret123","confirm":"secret123","seats":5}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "fr-CH, fr;q=0.9, en;q=0.8")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	var body struct {
+		Data []*core.ValidationError `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "email est déjà utilisé", body.Data[0].Message)
+
+	assert.Equal(t, "de", core.TranslatorFor("de-AT;q=0.9").Locale())
+	assert.Equal(t, "en", core.TranslatorFor("sw").Locale())
+	assert.Equal(t, "de", core.TranslatorFor("fr;q=0.5, de;q=0.9").Locale())
+	assert.Equal(t, "es", core.TranslatorFor("fr;q=0, es").Locale())
+	assert.Equal(t, "nl", core.TranslatorFor("pt;q=0.2, nl;q=0.7, it;q=0.7").Locale())
+}
+
+type OrderLineDto struct {
+	Sku      string `json:"sku" validate:"required"`
+	Quantity int    `json:"quantity" validate:"gte=1"`
+}
+
+type PlaceOrderDto struct {
+	Customer struct {
+		Email string `json:"email" validate:"required,email"`
+	} `json:"customer"`
+	Items []OrderLineDto `json:"items" validate:"required,dive"`
+}
+
+func TestNestedValidationPaths(t *testing.T) {
+	dto := PlaceOrderDto{Items: []OrderLineDto{{Sku: "A-1", Quantity: 1}, {Sku: "A-2", Quantity: 0}, {Quantity: 1}}}
+	dto.Customer.Email = "not-an-email"
+
+	pointers := map[string]string{}
+	for _, e := range core.ValidateStruct(&dto) {
+		pointers[e.Field] = e.Pointer
+	}
+	assert.Equal(t, map[string]string{
+		"customer.email":    "/customer/email",
+		"items[1].quantity": "/items/1/quantity",
+		"items[2].sku":      "/items/2/sku",
+	}, pointers)
+
+	errs := core.ValidateStruct([]OrderLineDto{{Sku: "B-1", Quantity: 1}, {Sku: "", Quantity: 2}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "[1].sku", errs[0].Field)
+	assert.Equal(t, "/1/sku", errs[0].Pointer)
+
+	errs = core.ValidateStruct(map[string]OrderLineDto{"x/y": {Sku: "C-1"}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "/x~1y/quantity", errs[0].Pointer)
+
+	errs = core.ValidateStruct(42)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "invalid", errs[0].Tag)
+
+	grouped := core.GroupByField(core.ValidateStruct(PlaceOrderDto{}))
+	assert.Contains(t, grouped, "customer.email")
+	assert.Contains(t, grouped, "items")
+}
+
+func TestRegisterValidationWhileValidating(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			core.ValidateStruct(PlaceOrderDto{})
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		require.NoError(t, core.RegisterValidation(fmt.Sprintf("concurrent_rule_%d", i), func(fl validator.FieldLevel) bool {
+			return true
+		}, "{0} is invalid"))
+	}
+	<-done
+}
@@ -1,14 +1,62 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/go-playground/locales"
+	deLocale "github.com/go-playground/locales/de"
+	enLocale "github.com/go-playground/locales/en"
+	esLocale "github.com/go-playground/locales/es"
+	frLocale "github.com/go-playground/locales/fr"
+	itLocale "github.com/go-playground/locales/it"
+	nlLocale "github.com/go-playground/locales/nl"
+	ptLocale "github.com/go-playground/locales/pt"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	deTranslations "github.com/go-playground/validator/v10/translations/de"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	esTranslations "github.com/go-playground/validator/v10/translations/es"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	itTranslations "github.com/go-playground/validator/v10/translations/it"
+	nlTranslations "github.com/go-playground/validator/v10/translations/nl"
+	ptTranslations "github.com/go-playground/validator/v10/translations/pt"
+	"github.com/gofiber/fiber/v2"
 )
 
-var validate = validator.New()
+var (
+	validate = validator.New()
+	// validateMu guards rule registrations against validations running concurrently
+	validateMu sync.RWMutex
+)
+
+// DefaultLocale is used when the request does not ask for a supported language
+const DefaultLocale = "en"
+
+var (
+	translators   = ut.New(enLocale.New())
+	translatorsMu sync.RWMutex
+)
+
+// supportedLocales lists the languages with built-in validation messages
+var supportedLocales = []struct {
+	locale   locales.Translator
+	register func(v *validator.Validate, trans ut.Translator) error
+}{
+	{enLocale.New(), enTranslations.RegisterDefaultTranslations},
+	{frLocale.New(), frTranslations.RegisterDefaultTranslations},
+	{esLocale.New(), esTranslations.RegisterDefaultTranslations},
+	{deLocale.New(), deTranslations.RegisterDefaultTranslations},
+	{ptLocale.New(), ptTranslations.RegisterDefaultTranslations},
+	{itLocale.New(), itTranslations.RegisterDefaultTranslations},
+	{nlLocale.New(), nlTranslations.RegisterDefaultTranslations},
+}
 
 func init() {
 	// Register function to get json tag name for errors
@@ -19,43 +67,280 @@ func init() {
 		}
 		return name
 	})
+
+	// Register the built-in messages of every supported language
+	for _, supported := range supportedLocales {
+		if err := translators.AddTranslator(supported.locale, true); err != nil {
+			panic(err)
+		}
+		trans, _ := translators.GetTranslator(supported.locale.Locale())
+		if err := supported.register(validate, trans); err != nil {
+			panic(fmt.Sprintf("failed to register %s validation messages: %v", supported.locale.Locale(), err))
+		}
+	}
 }
 
-// ValidationError represents a friendly validation error
+// ValidationError represents a friendly validation error. Field is the path of the
+// value using json names (e.g. "items[2].sku") and Pointer the same location as a
+// JSON pointer ("/items/2/sku").
 type ValidationError struct {
 	Field   string `json:"field"`
+	Pointer string `json:"pointer,omitempty"`
 	Tag     string `json:"tag"`
 	Message string `json:"message"`
 }
 
-// ValidateStruct validates a struct and returns friendly errors
+// RegisterValidation adds a custom rule usable in `validate:"…"` tags. The message may
+// reference the field as {0} and the rule parameter as {1}, e.g. "{0} must be a multiple of {1}".
+// Rules needing services (e.g. checking a unique email in the database) can capture
+// them in fn after resolving them from the container.
+func RegisterValidation(tag string, fn validator.Func, message string) error {
+	validateMu.Lock()
+	err := validate.RegisterValidation(tag, fn)
+	validateMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return registerMessage(tag, message)
+}
+
+// RegisterValidationCtx adds a custom rule that receives the context passed to
+// ValidateStructCtx (the request context when validating through Bind)
+func RegisterValidationCtx(tag string, fn validator.FuncCtx, message string) error {
+	validateMu.Lock()
+	err := validate.RegisterValidationCtx(tag, fn)
+	validateMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return registerMessage(tag, message)
+}
+
+// RegisterStructValidation adds a validation spanning several fields of the given types.
+// Report failures with sl.ReportError; the tag used there selects the message.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	validateMu.Lock()
+	defer validateMu.Unlock()
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterTranslation overrides the message of a tag for one locale
+func RegisterTranslation(locale string, tag string, message string) error {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+
+	trans, found := translators.GetTranslator(locale)
+	if !found {
+		return fmt.Errorf("unsupported validation locale %s", locale)
+	}
+	return addTranslation(trans, tag, message)
+}
+
+// registerMessage uses message as the default text of tag in every supported locale
+func registerMessage(tag string, message string) error {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+
+	for _, supported := range supportedLocales {
+		trans, _ := translators.GetTranslator(supported.locale.Locale())
+		if err := addTranslation(trans, tag, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addTranslation(trans ut.Translator, tag string, message string) error {
+	return validate.RegisterTranslation(tag, trans,
+		func(t ut.Translator) error {
+			return t.Add(tag, message, true)
+		},
+		func(t ut.Translator, fe validator.FieldError) string {
+			msg, err := t.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fallbackMessage(fe)
+			}
+			return msg
+		},
+	)
+}
+
+// TranslatorFor picks the best supported translator for an Accept-Language header
+func TranslatorFor(acceptLanguage string) ut.Translator {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+
+	trans, _ := translators.FindTranslator(localeCandidates(acceptLanguage)...)
+	return trans
+}
+
+// localeCandidates turns "en;q=0.8, fr-CH, fr;q=0.9" into [fr_CH fr fr en]: ranges
+// sorted by q-value, header order kept among equal weights, q=0 ranges dropped
+func localeCandidates(acceptLanguage string) []string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		// q=0 means "not acceptable"
+		if quality > 0 {
+			ranges = append(ranges, languageRange{tag: tag, quality: quality})
+		}
+	}
+	// Most preferred first, keeping the header order among equal weights
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	var candidates []string
+	for _, r := range ranges {
+		tag := strings.ReplaceAll(r.tag, "-", "_")
+		candidates = append(candidates, tag)
+		if base, _, found := strings.Cut(tag, "_"); found {
+			candidates = append(candidates, strings.ToLower(base))
+		}
+	}
+	return append(candidates, DefaultLocale)
+}
+
+// ValidateStruct validates a struct, or a slice or map of structs, and returns friendly errors
 func ValidateStruct(s any) []*ValidationError {
-	err := validate.Struct(s)
+	return validateWith(context.Background(), s, TranslatorFor(DefaultLocale))
+}
+
+// ValidateStructCtx validates a struct, passing ctx to context-aware rules
+func ValidateStructCtx(ctx context.Context, s any) []*ValidationError {
+	return validateWith(ctx, s, TranslatorFor(DefaultLocale))
+}
+
+// ValidateRequest validates a struct with messages in the language of the request
+func ValidateRequest(c *fiber.Ctx, s any) []*ValidationError {
+	return validateWith(c.UserContext(), s, TranslatorFor(c.Get(fiber.HeaderAcceptLanguage)))
+}
+
+func validateWith(ctx context.Context, s any, trans ut.Translator) []*ValidationError {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	// Collections of DTOs are validated element by element
+	var err error
+	validateMu.RLock()
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		err = validate.VarCtx(ctx, s, "dive")
+	default:
+		err = validate.StructCtx(ctx, s)
+	}
+	validateMu.RUnlock()
 	if err == nil {
 		return nil
 	}
 
-	var errors []*ValidationError
-	for _, err := range err.(validator.ValidationErrors) {
-		var element ValidationError
-		element.Field = err.Field()
-		element.Tag = err.Tag()
-		element.Message = msgForTag(err)
-		errors = append(errors, &element)
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return []*ValidationError{{
+			Tag:     "invalid",
+			Message: "Input must be a struct, or a slice or map of structs",
+		}}
+	}
+
+	results := make([]*ValidationError, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		path := fieldPath(fe.Namespace(), value.Kind() == reflect.Struct)
+		results = append(results, &ValidationError{
+			Field:   path,
+			Pointer: jsonPointer(path),
+			Tag:     fe.Tag(),
+			Message: messageFor(fe, trans),
+		})
+	}
+	return results
+}
+
+// GroupByField groups error messages by field path, e.g. {"items[2].sku": ["sku is a required field"]}
+func GroupByField(errs []*ValidationError) map[string][]string {
+	grouped := make(map[string][]string, len(errs))
+	for _, e := range errs {
+		grouped[e.Field] = append(grouped[e.Field], e.Message)
+	}
+	return grouped
+}
+
+// fieldPath drops the root struct name from a namespace such as "CreateOrderDto.items[2].sku"
+func fieldPath(namespace string, rootIsStruct bool) string {
+	if !rootIsStruct {
+		return namespace
+	}
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+// jsonPointer converts a field path such as "items[2].sku" to an RFC 6901 pointer ("/items/2/sku")
+func jsonPointer(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.IndexByte(part, '[')
+			if open < 0 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.IndexByte(part[open:], ']')
+			if end < 0 {
+				segments = append(segments, part[open+1:])
+				break
+			}
+			segments = append(segments, part[open+1:open+end])
+			part = part[open+end+1:]
+		}
+	}
+
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	for i, segment := range segments {
+		segments[i] = escaper.Replace(segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// messageFor translates a field error, falling back to a generic message for rules
+// without a translation in the selected language
+func messageFor(fe validator.FieldError, trans ut.Translator) string {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+
+	if msg := fe.Translate(trans); msg != fe.Error() {
+		return msg
 	}
-	return errors
+	return fallbackMessage(fe)
 }
 
-func msgForTag(fe validator.FieldError) string {
-	switch fe.Tag() {
-	case "required":
-		return fmt.Sprintf("This field is required")
-	case "email":
-		return fmt.Sprintf("Invalid email format")
-	case "min":
-		return fmt.Sprintf("Must be at least %s characters long", fe.Param())
-	case "max":
-		return fmt.Sprintf("Must be at most %s characters long", fe.Param())
+func fallbackMessage(fe validator.FieldError) string {
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s failed the %s=%s rule", fe.Field(), fe.Tag(), fe.Param())
 	}
-	return fe.Error() // Default error
+	return fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
 }